@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/Bo0mer/gentools/cmd/internal/fakegen"
+	"github.com/Bo0mer/gentools/pkg/plugin"
+)
+
+func main() {
+	plugin.MainPlugin(fakegen.Plugin)
+}
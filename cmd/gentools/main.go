@@ -0,0 +1,21 @@
+package main
+
+import (
+	_ "github.com/Bo0mer/gentools/cmd/internal/breakergen"
+	_ "github.com/Bo0mer/gentools/cmd/internal/cachegen"
+	_ "github.com/Bo0mer/gentools/cmd/internal/fakegen"
+	_ "github.com/Bo0mer/gentools/cmd/internal/interceptgen"
+	_ "github.com/Bo0mer/gentools/cmd/internal/logen"
+	_ "github.com/Bo0mer/gentools/cmd/internal/mongen"
+	_ "github.com/Bo0mer/gentools/cmd/internal/ratelimitgen"
+	_ "github.com/Bo0mer/gentools/cmd/internal/retrygen"
+	_ "github.com/Bo0mer/gentools/cmd/internal/templgen"
+	_ "github.com/Bo0mer/gentools/cmd/internal/timeoutgen"
+	_ "github.com/Bo0mer/gentools/cmd/internal/tracegen"
+	_ "github.com/Bo0mer/gentools/cmd/internal/validategen"
+	"github.com/Bo0mer/gentools/pkg/plugin"
+)
+
+func main() {
+	plugin.Main()
+}
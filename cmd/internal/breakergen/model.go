@@ -0,0 +1,307 @@
+package breakergen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/Bo0mer/gentools/pkg/astgen"
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+const breakerPackagePath = "github.com/Bo0mer/gentools/pkg/middleware/breaker"
+
+type model struct {
+	fileBuilder *astgen.File
+	structName  string
+	strct       *astgen.Struct
+	constructor *constructorBuilder
+
+	breakerPackageAlias string
+}
+
+func newModel(interfacePath, interfaceName, structName, targetPkg string) *model {
+	file := astgen.NewFile(targetPkg)
+	strct := astgen.NewStruct(structName)
+	file.AppendDeclaration(strct)
+
+	m := &model{
+		fileBuilder: file,
+		structName:  structName,
+		strct:       strct,
+	}
+	sourcePackageAlias := m.AddImport("", interfacePath)
+	m.breakerPackageAlias = m.AddImport("", breakerPackagePath)
+
+	file.AppendDeclaration(astgen.NewInterfaceAssertion(sourcePackageAlias, interfaceName, structName))
+
+	m.constructor = newConstructorBuilder(m.breakerPackageAlias, sourcePackageAlias, interfaceName, structName)
+	file.AppendDeclaration(m.constructor)
+
+	strct.AddField("next", sourcePackageAlias, interfaceName)
+
+	return m
+}
+
+func (m *model) Build() *ast.File {
+	return m.fileBuilder.Build()
+}
+
+func (m *model) AddImport(pkgName, location string) string {
+	return m.fileBuilder.AddImport(pkgName, location)
+}
+
+func (m *model) AddMethod(method *astgen.MethodConfig) error {
+	// Only calls that can fail and can report the rejection through an
+	// error result are guarded by a breaker.
+	openResult, ok := method.ErrorResultName()
+	if method.FailureCondition == nil || !ok {
+		m.fileBuilder.AppendDeclaration(newCircuitBreakingMethodBuilder(m.structName, method, "", ""))
+		return nil
+	}
+
+	breakerField := breakerFieldName(method.MethodName)
+	m.strct.AddFieldWithType(breakerField, &ast.StarExpr{
+		X: &ast.SelectorExpr{
+			X:   ast.NewIdent(m.breakerPackageAlias),
+			Sel: ast.NewIdent("Breaker"),
+		},
+	})
+	m.constructor.addGuardedMethod(method.MethodName, breakerField)
+
+	m.fileBuilder.AppendDeclaration(newCircuitBreakingMethodBuilder(m.structName, method, breakerField, openResult))
+	return nil
+}
+
+func breakerFieldName(methodName string) string {
+	return transformation.ToUnexported(methodName) + "Breaker"
+}
+
+type constructorBuilder struct {
+	breakerPackageName   string
+	interfacePackageName string
+	interfaceName        string
+	structName           string
+
+	methodNames   []string
+	breakerFields []string
+}
+
+func newConstructorBuilder(breakerPackageName, packageName, interfaceName, structName string) *constructorBuilder {
+	return &constructorBuilder{
+		breakerPackageName:   breakerPackageName,
+		interfacePackageName: packageName,
+		interfaceName:        interfaceName,
+		structName:           structName,
+	}
+}
+
+func (c *constructorBuilder) addGuardedMethod(methodName, breakerField string) {
+	c.methodNames = append(c.methodNames, methodName)
+	c.breakerFields = append(c.breakerFields, breakerField)
+}
+
+// Build builds the constructor, which obtains the breakers of all guarded
+// methods upfront:
+//   return &circuitBreakingService{
+//     next:          next,
+//     doWorkBreaker: breakers.Breaker("DoWork"),
+//   }
+func (c *constructorBuilder) Build() ast.Decl {
+	elts := []ast.Expr{
+		&ast.KeyValueExpr{
+			Key:   ast.NewIdent("next"),
+			Value: ast.NewIdent("next"),
+		},
+	}
+	for i, methodName := range c.methodNames {
+		elts = append(elts, &ast.KeyValueExpr{
+			Key: ast.NewIdent(c.breakerFields[i]),
+			Value: &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   ast.NewIdent("breakers"),
+					Sel: ast.NewIdent("Breaker"),
+				},
+				Args: []ast.Expr{
+					&ast.BasicLit{Kind: token.STRING, Value: fmt.Sprintf("%q", methodName)},
+				},
+			},
+		})
+	}
+
+	funcBody := &ast.BlockStmt{
+		List: []ast.Stmt{
+			&ast.ReturnStmt{
+				Results: []ast.Expr{
+					&ast.UnaryExpr{
+						Op: token.AND,
+						X: &ast.CompositeLit{
+							Type: ast.NewIdent(c.structName),
+							Elts: elts,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	funcName := fmt.Sprintf("NewCircuitBreaking%s", c.interfaceName)
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{
+			List: []*ast.Comment{
+				{
+					Text: fmt.Sprintf("// %s creates new circuit breaking middleware.", funcName),
+				},
+			},
+		},
+		Name: ast.NewIdent(funcName),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("next")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interfacePackageName),
+							Sel: ast.NewIdent(c.interfaceName),
+						},
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("breakers")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.breakerPackageName),
+							Sel: ast.NewIdent("Breakers"),
+						},
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interfacePackageName),
+							Sel: ast.NewIdent(c.interfaceName),
+						},
+					},
+				},
+			},
+		},
+		Body: funcBody,
+	}
+}
+
+// circuitBreakingMethodBuilder is responsible for creating a method that
+// implements the original method from the interface and guards the call with
+// a circuit breaker.
+type circuitBreakingMethodBuilder struct {
+	methodConfig *astgen.MethodConfig
+	method       *astgen.Method
+	breakerField string
+	openResult   string
+}
+
+func newCircuitBreakingMethodBuilder(structName string, methodConfig *astgen.MethodConfig, breakerField, openResult string) *circuitBreakingMethodBuilder {
+	method := astgen.NewMethod(methodConfig.MethodName, "m", structName)
+
+	return &circuitBreakingMethodBuilder{
+		methodConfig: methodConfig,
+		method:       method,
+		breakerField: breakerField,
+		openResult:   openResult,
+	}
+}
+
+func (b *circuitBreakingMethodBuilder) Build() ast.Decl {
+	b.method.SetType(&ast.FuncType{
+		Params: &ast.FieldList{
+			List: b.methodConfig.MethodParams,
+		},
+		Results: &ast.FieldList{
+			List: transformation.FieldsAsAnonymous(b.methodConfig.MethodResults),
+		},
+	})
+
+	methodInvocation := astgen.NewMethodInvocation(b.methodConfig)
+	methodInvocation.SetReceiver(&ast.SelectorExpr{
+		X:   ast.NewIdent("m"), // receiver name
+		Sel: ast.NewIdent("next"),
+	})
+
+	// Unguarded methods are proxied:
+	//   return m.next.Method(arg1, arg2)
+	if b.breakerField == "" {
+		b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf("%s calls the wrapped method.", b.methodConfig.MethodName)))
+		b.method.AddStatement(methodInvocation.BuildReturn())
+		return b.method.Build()
+	}
+	b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf("%s calls the wrapped method through its circuit breaker, unless the circuit is open.", b.methodConfig.MethodName)))
+
+	breakerSel := &ast.SelectorExpr{
+		X:   ast.NewIdent("m"), // receiver name
+		Sel: ast.NewIdent(b.breakerField),
+	}
+
+	// Declare the results, so that rejected calls return zero values:
+	//   var result1 string
+	//   var result2 error
+	b.method.AddStatements(astgen.NewDeclareResults(b.methodConfig).Build())
+
+	// Reject the call if the circuit is open:
+	//   if result2 = m.doWorkBreaker.Allow(); result2 != nil {
+	//     return result1, result2
+	//   }
+	b.method.AddStatement(&ast.IfStmt{
+		Init: &ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(b.openResult)},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   breakerSel,
+						Sel: ast.NewIdent("Allow"),
+					},
+				},
+			},
+		},
+		Cond: &ast.BinaryExpr{
+			X:  ast.NewIdent(b.openResult),
+			Op: token.NEQ,
+			Y:  ast.NewIdent("nil"),
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{astgen.NewReturnResults(b.methodConfig).Build()},
+		},
+	})
+
+	// Record a panicking call as failed:
+	//   defer m.doWorkBreaker.Recover()
+	b.method.AddStatement(&ast.DeferStmt{
+		Call: &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   breakerSel,
+				Sel: ast.NewIdent("Recover"),
+			},
+		},
+	})
+
+	// Add method invocation:
+	//   result1, result2 = m.next.Method(arg1, arg2)
+	b.method.AddStatement(methodInvocation.BuildAssign())
+
+	// Record the outcome of the call:
+	//   m.doWorkBreaker.Done([failure condition])
+	b.method.AddStatement(&ast.ExprStmt{
+		X: &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   breakerSel,
+				Sel: ast.NewIdent("Done"),
+			},
+			Args: []ast.Expr{b.methodConfig.FailureCondition},
+		},
+	})
+
+	// Add return statement
+	//   return result1, result2
+	b.method.AddStatement(astgen.NewReturnResults(b.methodConfig).Build())
+
+	return b.method.Build()
+}
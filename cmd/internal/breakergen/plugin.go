@@ -0,0 +1,25 @@
+package breakergen
+
+import (
+	"fmt"
+
+	"github.com/Bo0mer/gentools/pkg/plugin"
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+// Plugin generates circuit breaking implementations of interfaces.
+var Plugin = plugin.Plugin{
+	Name:        "breakergen",
+	Description: "circuit breaking",
+	FileName: func(cfg plugin.Config) string {
+		return fmt.Sprintf("circuit_breaking_%s.go", transformation.ToSnakeCase(cfg.InterfaceName))
+	},
+	NewModel: func(cfg plugin.Config) (plugin.Model, error) {
+		typeName := fmt.Sprintf("circuitBreaking%s", cfg.InterfaceName)
+		return newModel(cfg.InterfacePath, cfg.InterfaceName, typeName, cfg.TargetPackage), nil
+	},
+}
+
+func init() {
+	plugin.Register(Plugin)
+}
@@ -0,0 +1,566 @@
+package cachegen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bo0mer/gentools/pkg/astgen"
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+const (
+	// cacheableAnnotation marks a read method whose results are cached. It
+	// optionally overrides the TTL passed to the constructor:
+	//   //gentools:cacheable
+	//   //gentools:cacheable 5m
+	cacheableAnnotation = "cacheable"
+
+	// invalidateAnnotation marks a write method whose calls invalidate the
+	// cached results of the listed methods, or of all cacheable methods if
+	// none are listed:
+	//   //gentools:invalidate
+	//   //gentools:invalidate Get List
+	invalidateAnnotation = "invalidate"
+
+	cachePackagePath = "github.com/Bo0mer/gentools/pkg/middleware/cache"
+)
+
+type model struct {
+	fileBuilder *astgen.File
+	structName  string
+
+	cachePackageAlias   string
+	timePackageAlias    string
+	contextPackageAlias string
+
+	cacheableMethods   []string
+	invalidatedMethods map[string]*astgen.MethodConfig
+}
+
+func newModel(interfacePath, interfaceName, structName, targetPkg string) *model {
+	file := astgen.NewFile(targetPkg)
+	strct := astgen.NewStruct(structName)
+	file.AppendDeclaration(strct)
+
+	m := &model{
+		fileBuilder:        file,
+		structName:         structName,
+		invalidatedMethods: make(map[string]*astgen.MethodConfig),
+	}
+	sourcePackageAlias := m.AddImport("", interfacePath)
+	m.cachePackageAlias = m.AddImport("", cachePackagePath)
+	m.timePackageAlias = m.AddImport("", "time")
+
+	file.AppendDeclaration(astgen.NewInterfaceAssertion(sourcePackageAlias, interfaceName, structName))
+
+	constructorBuilder := newConstructorBuilder(m.cachePackageAlias, m.timePackageAlias, sourcePackageAlias, interfaceName, structName)
+	file.AppendDeclaration(constructorBuilder)
+
+	strct.AddField("next", sourcePackageAlias, interfaceName)
+	strct.AddField("cache", m.cachePackageAlias, "Cache")
+	strct.AddField("ttl", m.timePackageAlias, "Duration")
+	strct.AddField("group", m.cachePackageAlias, "Group")
+
+	return m
+}
+
+// Validate reports the annotations which refer to unknown methods.
+func (m *model) Validate() error {
+	for name, invalidator := range m.invalidatedMethods {
+		if !m.isCacheable(name) {
+			return invalidator.WrapError(fmt.Errorf("%s annotation refers to method '%s', which is not %s", invalidateAnnotation, name, cacheableAnnotation))
+		}
+	}
+
+	return nil
+}
+
+func (m *model) Build() *ast.File {
+	return m.fileBuilder.Build()
+}
+
+func (m *model) AddImport(pkgName, location string) string {
+	if location == "context" {
+		m.contextPackageAlias = m.fileBuilder.AddImport(pkgName, location)
+		return m.contextPackageAlias
+	}
+	return m.fileBuilder.AddImport(pkgName, location)
+}
+
+func (m *model) AddMethod(method *astgen.MethodConfig) error {
+	mmb := newCachingMethodBuilder(m.structName, method, m.cachePackageAlias, m.fileBuilder.MethodScope(method, "m"))
+
+	if ttl, ok := method.Annotations.Lookup(cacheableAnnotation); ok {
+		if err := m.addCacheableMethod(mmb, ttl); err != nil {
+			return err
+		}
+	} else if value, ok := method.Annotations.Lookup(invalidateAnnotation); ok {
+		names := strings.FieldsFunc(value, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		for _, name := range names {
+			m.invalidatedMethods[name] = method
+		}
+		mmb.setInvalidated(func() []string {
+			if len(names) > 0 {
+				return names
+			}
+			// All cacheable methods are known only after the whole
+			// interface is processed.
+			return m.cacheableMethods
+		})
+	}
+
+	m.fileBuilder.AppendDeclaration(mmb)
+	return nil
+}
+
+func (m *model) addCacheableMethod(mmb *cachingMethodBuilder, ttl string) error {
+	method := mmb.methodConfig
+	if !method.HasResults() {
+		return fmt.Errorf("%s method has no results", cacheableAnnotation)
+	}
+
+	// The context is not part of the key.
+	params := method.MethodParams
+	if _, ok := method.ContextParamName(m.contextPackageAlias); ok {
+		params = params[1:]
+	}
+	for _, param := range params {
+		if !isComparableParam(method, param) {
+			return fmt.Errorf("%s method has parameter '%s' of non-comparable type", cacheableAnnotation, param.Names[0].String())
+		}
+	}
+	mmb.setKeyParams(params)
+
+	ttlExpr := ast.Expr(&ast.SelectorExpr{
+		X:   ast.NewIdent("m"), // receiver name
+		Sel: ast.NewIdent("ttl"),
+	})
+	if ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid %s annotation %q: expected positive duration", cacheableAnnotation, ttl)
+		}
+		ttlExpr = durationExpr(m.timePackageAlias, d)
+	}
+	mmb.setTTL(ttlExpr)
+
+	m.cacheableMethods = append(m.cacheableMethods, method.MethodName)
+	return nil
+}
+
+func (m *model) isCacheable(name string) bool {
+	for _, cacheable := range m.cacheableMethods {
+		if cacheable == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isComparableParam(method *astgen.MethodConfig, param *ast.Field) bool {
+	for _, comparable := range method.ComparableParams {
+		if comparable == param {
+			return true
+		}
+	}
+	return false
+}
+
+var durationUnits = []struct {
+	unit time.Duration
+	name string
+}{
+	{time.Hour, "Hour"},
+	{time.Minute, "Minute"},
+	{time.Second, "Second"},
+	{time.Millisecond, "Millisecond"},
+	{time.Microsecond, "Microsecond"},
+	{time.Nanosecond, "Nanosecond"},
+}
+
+// durationExpr builds an expression of the specified duration in the
+// largest unit that represents it exactly:
+//   90 * time.Second
+func durationExpr(timePackageAlias string, d time.Duration) ast.Expr {
+	for _, u := range durationUnits {
+		if d%u.unit != 0 {
+			continue
+		}
+		unit := &ast.SelectorExpr{
+			X:   ast.NewIdent(timePackageAlias),
+			Sel: ast.NewIdent(u.name),
+		}
+		if d == u.unit {
+			return unit
+		}
+		return &ast.BinaryExpr{
+			X:  &ast.BasicLit{Kind: token.INT, Value: strconv.FormatInt(int64(d/u.unit), 10)},
+			Op: token.MUL,
+			Y:  unit,
+		}
+	}
+	panic("unreachable")
+}
+
+type constructorBuilder struct {
+	cachePackageName     string
+	timePackageName      string
+	interfacePackageName string
+	interfaceName        string
+	structName           string
+}
+
+func newConstructorBuilder(cachePackageName, timePackageName, packageName, interfaceName, structName string) *constructorBuilder {
+	return &constructorBuilder{
+		cachePackageName:     cachePackageName,
+		timePackageName:      timePackageName,
+		interfacePackageName: packageName,
+		interfaceName:        interfaceName,
+		structName:           structName,
+	}
+}
+
+func (c *constructorBuilder) Build() ast.Decl {
+	funcBody := &ast.BlockStmt{
+		List: []ast.Stmt{
+			&ast.ReturnStmt{
+				Results: []ast.Expr{
+					&ast.UnaryExpr{
+						Op: token.AND,
+						X: &ast.CompositeLit{
+							Type: ast.NewIdent(c.structName),
+							Elts: []ast.Expr{
+								&ast.KeyValueExpr{Key: ast.NewIdent("next"), Value: ast.NewIdent("next")},
+								&ast.KeyValueExpr{Key: ast.NewIdent("cache"), Value: ast.NewIdent("cache")},
+								&ast.KeyValueExpr{Key: ast.NewIdent("ttl"), Value: ast.NewIdent("ttl")},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	funcName := fmt.Sprintf("NewCaching%s", c.interfaceName)
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{
+			List: []*ast.Comment{
+				{
+					Text: fmt.Sprintf("// %s creates new caching middleware.", funcName),
+				},
+			},
+		},
+		Name: ast.NewIdent(funcName),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("next")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interfacePackageName),
+							Sel: ast.NewIdent(c.interfaceName),
+						},
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("cache")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.cachePackageName),
+							Sel: ast.NewIdent("Cache"),
+						},
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("ttl")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.timePackageName),
+							Sel: ast.NewIdent("Duration"),
+						},
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interfacePackageName),
+							Sel: ast.NewIdent(c.interfaceName),
+						},
+					},
+				},
+			},
+		},
+		Body: funcBody,
+	}
+}
+
+// cachingMethodBuilder is responsible for creating a method that implements
+// the original method from the interface and caches its results or
+// invalidates the cached results of other methods.
+type cachingMethodBuilder struct {
+	methodConfig      *astgen.MethodConfig
+	method            *astgen.Method
+	cachePackageAlias string
+	scope             *astgen.Scope
+
+	cacheable bool
+	keyParams []*ast.Field
+	ttl       ast.Expr
+
+	invalidated func() []string
+}
+
+func newCachingMethodBuilder(structName string, methodConfig *astgen.MethodConfig, cachePackageAlias string, scope *astgen.Scope) *cachingMethodBuilder {
+	method := astgen.NewMethod(methodConfig.MethodName, "m", structName)
+
+	return &cachingMethodBuilder{
+		methodConfig:      methodConfig,
+		method:            method,
+		cachePackageAlias: cachePackageAlias,
+		scope:             scope,
+	}
+}
+
+// setKeyParams makes the method cache its results under a key built from
+// the specified parameters.
+func (b *cachingMethodBuilder) setKeyParams(params []*ast.Field) {
+	b.cacheable = true
+	b.keyParams = params
+}
+
+func (b *cachingMethodBuilder) setTTL(ttl ast.Expr) {
+	b.ttl = ttl
+}
+
+// setInvalidated makes the method invalidate the cached results of the
+// methods returned by the specified function.
+func (b *cachingMethodBuilder) setInvalidated(invalidated func() []string) {
+	b.invalidated = invalidated
+}
+
+func (b *cachingMethodBuilder) Build() ast.Decl {
+	b.method.SetType(&ast.FuncType{
+		Params: &ast.FieldList{
+			List: b.methodConfig.MethodParams,
+		},
+		Results: &ast.FieldList{
+			List: transformation.FieldsAsAnonymous(b.methodConfig.MethodResults),
+		},
+	})
+
+	methodInvocation := astgen.NewMethodInvocation(b.methodConfig)
+	methodInvocation.SetReceiver(&ast.SelectorExpr{
+		X:   ast.NewIdent("m"), // receiver name
+		Sel: ast.NewIdent("next"),
+	})
+
+	switch {
+	case b.cacheable:
+		b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf("%s returns the cached results of the wrapped method, calling it only if they\nare missing.", b.methodConfig.MethodName)))
+		b.method.AddStatements(b.cachedCallStmts(methodInvocation))
+	case b.invalidated != nil:
+		// Add method invocation, followed by the invalidation of the
+		// affected results:
+		//   result1 := m.next.Method(arg1, arg2)
+		//   m.group.Invalidate(m.cache, "Get")
+		//   return result1
+		invalidated := b.invalidated()
+		b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf("%s calls the wrapped method and invalidates the cached results of\n%s.", b.methodConfig.MethodName, strings.Join(invalidated, ", "))))
+		b.method.AddStatement(methodInvocation.Build())
+		for _, name := range invalidated {
+			b.method.AddStatement(&ast.ExprStmt{
+				X: &ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   b.receiverSelector("group"),
+						Sel: ast.NewIdent("Invalidate"),
+					},
+					Args: []ast.Expr{
+						b.receiverSelector("cache"),
+						&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(name)},
+					},
+				},
+			})
+		}
+		if b.methodConfig.HasResults() {
+			b.method.AddStatement(astgen.NewReturnResults(b.methodConfig).Build())
+		}
+	default:
+		// Add method invocation:
+		//   return m.next.Method(arg1, arg2)
+		b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf("%s calls the wrapped method.", b.methodConfig.MethodName)))
+		b.method.AddStatement(methodInvocation.BuildReturn())
+	}
+
+	return b.method.Build()
+}
+
+// cachedCallStmts builds the statements which return the cached results or
+// make a de-duplicated call and cache its results, unless it failed:
+//   key := cache.Key{Method: "Get", Args: [1]interface{}{arg2}}
+//   cached, ok := m.cache.Get(key)
+//   if !ok {
+//     cached = m.group.Do(m.cache, key, m.ttl, func() (interface{}, bool) {
+//       result1, result2 := m.next.Get(arg1, arg2)
+//       return []interface{}{result1, result2}, result2 == nil
+//     })
+//   }
+//   results := cached.([]interface{})
+//   result1, _ := results[0].([]byte)
+//   result2, _ := results[1].(error)
+//   return result1, result2
+func (b *cachingMethodBuilder) cachedCallStmts(methodInvocation *astgen.MethodInvocation) []ast.Stmt {
+	interfaceType := func() ast.Expr {
+		return &ast.InterfaceType{Methods: &ast.FieldList{Opening: 1, Closing: 1}}
+	}
+	resultsType := func() ast.Expr {
+		return &ast.ArrayType{Elt: interfaceType()}
+	}
+	var (
+		key     = b.scope.Name("key")
+		cached  = b.scope.Name("cached")
+		ok      = b.scope.Name("ok")
+		results = b.scope.Name("results")
+	)
+
+	keyElts := []ast.Expr{
+		&ast.KeyValueExpr{
+			Key:   ast.NewIdent("Method"),
+			Value: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(b.methodConfig.MethodName)},
+		},
+	}
+	if len(b.keyParams) > 0 {
+		var args []ast.Expr
+		for _, param := range b.keyParams {
+			args = append(args, ast.NewIdent(param.Names[0].String()))
+		}
+		keyElts = append(keyElts, &ast.KeyValueExpr{
+			Key: ast.NewIdent("Args"),
+			Value: &ast.CompositeLit{
+				Type: &ast.ArrayType{
+					Len: &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(len(args))},
+					Elt: interfaceType(),
+				},
+				Elts: args,
+			},
+		})
+	}
+
+	var resultIdents []ast.Expr
+	for _, result := range b.methodConfig.MethodResults {
+		resultIdents = append(resultIdents, ast.NewIdent(result.Names[0].String()))
+	}
+
+	cacheable := b.methodConfig.SuccessCondition()
+	if cacheable == nil {
+		cacheable = ast.NewIdent("true")
+	}
+	doStmts := []ast.Stmt{
+		methodInvocation.Build(),
+		&ast.ReturnStmt{
+			Results: []ast.Expr{
+				&ast.CompositeLit{Type: resultsType(), Elts: resultIdents},
+				cacheable,
+			},
+		},
+	}
+
+	stmts := []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(key)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CompositeLit{
+					Type: &ast.SelectorExpr{
+						X:   ast.NewIdent(b.cachePackageAlias),
+						Sel: ast.NewIdent("Key"),
+					},
+					Elts: keyElts,
+				},
+			},
+		},
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(cached), ast.NewIdent(ok)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   b.receiverSelector("cache"),
+						Sel: ast.NewIdent("Get"),
+					},
+					Args: []ast.Expr{ast.NewIdent(key)},
+				},
+			},
+		},
+		&ast.IfStmt{
+			Cond: &ast.UnaryExpr{Op: token.NOT, X: ast.NewIdent(ok)},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{ast.NewIdent(cached)},
+						Tok: token.ASSIGN,
+						Rhs: []ast.Expr{
+							&ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X:   b.receiverSelector("group"),
+									Sel: ast.NewIdent("Do"),
+								},
+								Args: []ast.Expr{
+									b.receiverSelector("cache"),
+									ast.NewIdent(key),
+									b.ttl,
+									&ast.FuncLit{
+										Type: &ast.FuncType{
+											Params: &ast.FieldList{},
+											Results: &ast.FieldList{
+												List: []*ast.Field{
+													{Type: interfaceType()},
+													{Type: ast.NewIdent("bool")},
+												},
+											},
+										},
+										Body: &ast.BlockStmt{List: doStmts},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(results)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.TypeAssertExpr{X: ast.NewIdent(cached), Type: resultsType()},
+			},
+		},
+	}
+	for i, result := range b.methodConfig.MethodResults {
+		stmts = append(stmts, &ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(result.Names[0].String()), ast.NewIdent("_")},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.TypeAssertExpr{
+					X: &ast.IndexExpr{
+						X:     ast.NewIdent(results),
+						Index: &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(i)},
+					},
+					Type: result.Type,
+				},
+			},
+		})
+	}
+	stmts = append(stmts, astgen.NewReturnResults(b.methodConfig).Build())
+	return stmts
+}
+
+func (b *cachingMethodBuilder) receiverSelector(field string) ast.Expr {
+	return &ast.SelectorExpr{
+		X:   ast.NewIdent("m"), // receiver name
+		Sel: ast.NewIdent(field),
+	}
+}
@@ -0,0 +1,479 @@
+package fakegen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/Bo0mer/gentools/pkg/astgen"
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+const receiverName = "fake"
+
+type model struct {
+	fileBuilder *astgen.File
+	structName  string
+	strct       *astgen.Struct
+
+	syncPackageAlias string
+}
+
+func newModel(interfacePath, interfaceName, structName, targetPkg string) *model {
+	file := astgen.NewFile(targetPkg)
+	strct := astgen.NewStruct(structName)
+	file.AppendDeclaration(strct)
+
+	m := &model{
+		fileBuilder: file,
+		structName:  structName,
+		strct:       strct,
+	}
+	sourcePackageAlias := m.AddImport("", interfacePath)
+	m.syncPackageAlias = m.AddImport("", "sync")
+
+	file.AppendDeclaration(astgen.NewInterfaceAssertion(sourcePackageAlias, interfaceName, structName))
+
+	return m
+}
+
+func (m *model) Build() *ast.File {
+	return m.fileBuilder.Build()
+}
+
+func (m *model) AddImport(pkgName, location string) string {
+	return m.fileBuilder.AddImport(pkgName, location)
+}
+
+func (m *model) AddMethod(method *astgen.MethodConfig) error {
+	names := newFakeMethodNames(method.MethodName)
+
+	// Add the fields which record the calls and hold the configured
+	// behaviour:
+	//   DoWorkStub        func(arg1 int) (string, error)
+	//   doWorkMutex       sync.RWMutex
+	//   doWorkArgsForCall []struct{ arg1 int }
+	//   doWorkReturns     struct{ result1 string; result2 error }
+	m.strct.AddFieldWithType(names.stub, stubFuncType(method))
+	m.strct.AddFieldWithType(names.mutex, &ast.SelectorExpr{
+		X:   ast.NewIdent(m.syncPackageAlias),
+		Sel: ast.NewIdent("RWMutex"),
+	})
+	m.strct.AddFieldWithType(names.argsForCall, &ast.ArrayType{
+		Elt: argsStructType(method),
+	})
+	if method.HasResults() {
+		m.strct.AddFieldWithType(names.returns, returnsStructType(method))
+	}
+
+	m.fileBuilder.AppendDeclaration(newFakeMethodBuilder(m.structName, names, method, m.fileBuilder.MethodScope(method, receiverName)))
+	m.fileBuilder.AppendDeclaration(newCallCountBuilder(m.structName, names, method))
+	m.fileBuilder.AppendDeclaration(newCallsBuilder(m.structName, names, method))
+	if method.HasParams() {
+		m.fileBuilder.AppendDeclaration(newArgsForCallBuilder(m.structName, names, method))
+	}
+	if method.HasResults() {
+		m.fileBuilder.AppendDeclaration(newReturnsBuilder(m.structName, names, method))
+	}
+	return nil
+}
+
+// fakeMethodNames holds the names of all struct fields and methods generated
+// for a single interface method.
+type fakeMethodNames struct {
+	method      string
+	stub        string
+	mutex       string
+	argsForCall string
+	returns     string
+}
+
+func newFakeMethodNames(methodName string) fakeMethodNames {
+	prefix := transformation.ToUnexported(methodName)
+
+	return fakeMethodNames{
+		method:      methodName,
+		stub:        methodName + "Stub",
+		mutex:       prefix + "Mutex",
+		argsForCall: prefix + "ArgsForCall",
+		returns:     prefix + "Returns",
+	}
+}
+
+// stubFuncType returns the type of the stub func that can replace the method,
+// e.g. func(int, ...string) (string, error).
+func stubFuncType(method *astgen.MethodConfig) *ast.FuncType {
+	return &ast.FuncType{
+		Params: &ast.FieldList{
+			List: transformation.FieldsAsAnonymous(method.MethodParams),
+		},
+		Results: &ast.FieldList{
+			List: transformation.FieldsAsAnonymous(method.MethodResults),
+		},
+	}
+}
+
+// argsStructType returns a struct type with a field for each parameter of
+// the method. Variadic parameters are stored as slices.
+func argsStructType(method *astgen.MethodConfig) *ast.StructType {
+	return &ast.StructType{
+		Fields: &ast.FieldList{
+			List: transformation.FieldsWithoutEllipsis(method.MethodParams),
+		},
+	}
+}
+
+// returnsStructType returns a struct type with a field for each result of the
+// method.
+func returnsStructType(method *astgen.MethodConfig) *ast.StructType {
+	return &ast.StructType{
+		Fields: &ast.FieldList{
+			List: method.MethodResults,
+		},
+	}
+}
+
+func fieldSelector(name string) *ast.SelectorExpr {
+	return &ast.SelectorExpr{
+		X:   ast.NewIdent(receiverName),
+		Sel: ast.NewIdent(name),
+	}
+}
+
+// mutexCallStmt builds a call to the specified method of the mutex:
+//   fake.doWorkMutex.Lock()
+func mutexCallStmt(mutexName, methodName string) *ast.ExprStmt {
+	return &ast.ExprStmt{
+		X: &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   fieldSelector(mutexName),
+				Sel: ast.NewIdent(methodName),
+			},
+		},
+	}
+}
+
+// deferredMutexCallStmt builds a deferred call to the specified method of the
+// mutex:
+//   defer fake.doWorkMutex.Unlock()
+func deferredMutexCallStmt(mutexName, methodName string) *ast.DeferStmt {
+	return &ast.DeferStmt{
+		Call: mutexCallStmt(mutexName, methodName).X.(*ast.CallExpr),
+	}
+}
+
+func fieldNames(fields []*ast.Field) []ast.Expr {
+	var names []ast.Expr
+	for _, field := range fields {
+		names = append(names, ast.NewIdent(field.Names[0].String()))
+	}
+	return names
+}
+
+// fakeMethodBuilder is responsible for creating the method that implements
+// the original method from the interface, records the call and delegates
+// to the stub or returns the configured results.
+type fakeMethodBuilder struct {
+	names        fakeMethodNames
+	methodConfig *astgen.MethodConfig
+	method       *astgen.Method
+	scope        *astgen.Scope
+}
+
+func newFakeMethodBuilder(structName string, names fakeMethodNames, methodConfig *astgen.MethodConfig, scope *astgen.Scope) *fakeMethodBuilder {
+	return &fakeMethodBuilder{
+		names:        names,
+		methodConfig: methodConfig,
+		method:       astgen.NewMethod(methodConfig.MethodName, receiverName, structName),
+		scope:        scope,
+	}
+}
+
+func (b *fakeMethodBuilder) Build() ast.Decl {
+	b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf("%s records the call and returns the results of the stub, if set, or\nthe configured results.", b.names.method)))
+	b.method.SetType(&ast.FuncType{
+		Params: &ast.FieldList{
+			List: b.methodConfig.MethodParams,
+		},
+		Results: &ast.FieldList{
+			List: transformation.FieldsAsAnonymous(b.methodConfig.MethodResults),
+		},
+	})
+
+	// fake.doWorkMutex.Lock()
+	b.method.AddStatement(mutexCallStmt(b.names.mutex, "Lock"))
+
+	// fake.doWorkArgsForCall = append(fake.doWorkArgsForCall, struct{...}{arg1, arg2})
+	b.method.AddStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{fieldSelector(b.names.argsForCall)},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{
+			&ast.CallExpr{
+				Fun: ast.NewIdent("append"),
+				Args: []ast.Expr{
+					fieldSelector(b.names.argsForCall),
+					&ast.CompositeLit{
+						Type: argsStructType(b.methodConfig),
+						Elts: fieldNames(b.methodConfig.MethodParams),
+					},
+				},
+			},
+		},
+	})
+
+	// stub := fake.DoWorkStub
+	stub := b.scope.Name("stub")
+	b.method.AddStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{ast.NewIdent(stub)},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{fieldSelector(b.names.stub)},
+	})
+
+	// returns := fake.doWorkReturns
+	var returns string
+	if b.methodConfig.HasResults() {
+		returns = b.scope.Name("returns")
+		b.method.AddStatement(&ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(returns)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{fieldSelector(b.names.returns)},
+		})
+	}
+
+	// fake.doWorkMutex.Unlock()
+	b.method.AddStatement(mutexCallStmt(b.names.mutex, "Unlock"))
+
+	// if stub != nil {
+	//   return stub(arg1, arg2)
+	// }
+	ellipsisPos := token.NoPos
+	for _, param := range b.methodConfig.MethodParams {
+		if p, ok := param.Type.(*ast.Ellipsis); ok {
+			ellipsisPos = p.Pos()
+		}
+	}
+	stubCall := &ast.CallExpr{
+		Fun:      ast.NewIdent(stub),
+		Args:     fieldNames(b.methodConfig.MethodParams),
+		Ellipsis: ellipsisPos,
+	}
+	stubCallStmts := []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{stubCall}}}
+	if !b.methodConfig.HasResults() {
+		stubCallStmts = []ast.Stmt{&ast.ExprStmt{X: stubCall}, &ast.ReturnStmt{}}
+	}
+	b.method.AddStatement(&ast.IfStmt{
+		Cond: &ast.BinaryExpr{
+			X:  ast.NewIdent(stub),
+			Op: token.NEQ,
+			Y:  ast.NewIdent("nil"),
+		},
+		Body: &ast.BlockStmt{
+			List: stubCallStmts,
+		},
+	})
+
+	// return returns.result1, returns.result2
+	if b.methodConfig.HasResults() {
+		var results []ast.Expr
+		for _, result := range b.methodConfig.MethodResults {
+			results = append(results, &ast.SelectorExpr{
+				X:   ast.NewIdent(returns),
+				Sel: ast.NewIdent(result.Names[0].String()),
+			})
+		}
+		b.method.AddStatement(&ast.ReturnStmt{Results: results})
+	}
+
+	return b.method.Build()
+}
+
+type callCountBuilder struct {
+	names        fakeMethodNames
+	methodConfig *astgen.MethodConfig
+	method       *astgen.Method
+}
+
+func newCallCountBuilder(structName string, names fakeMethodNames, methodConfig *astgen.MethodConfig) *callCountBuilder {
+	return &callCountBuilder{
+		names:        names,
+		methodConfig: methodConfig,
+		method:       astgen.NewMethod(names.method+"CallCount", receiverName, structName),
+	}
+}
+
+// Build builds a method that returns the number of recorded calls:
+//   func (fake *FakeService) DoWorkCallCount() int {
+//     fake.doWorkMutex.RLock()
+//     defer fake.doWorkMutex.RUnlock()
+//     return len(fake.doWorkArgsForCall)
+//   }
+func (b *callCountBuilder) Build() ast.Decl {
+	b.method.SetDoc(b.methodConfig.DeprecatedDoc(fmt.Sprintf("%sCallCount returns the number of calls to %s.", b.names.method, b.names.method)))
+	b.method.SetType(&ast.FuncType{
+		Params: &ast.FieldList{},
+		Results: &ast.FieldList{
+			List: []*ast.Field{{Type: ast.NewIdent("int")}},
+		},
+	})
+	b.method.AddStatement(mutexCallStmt(b.names.mutex, "RLock"))
+	b.method.AddStatement(deferredMutexCallStmt(b.names.mutex, "RUnlock"))
+	b.method.AddStatement(&ast.ReturnStmt{
+		Results: []ast.Expr{
+			&ast.CallExpr{
+				Fun:  ast.NewIdent("len"),
+				Args: []ast.Expr{fieldSelector(b.names.argsForCall)},
+			},
+		},
+	})
+	return b.method.Build()
+}
+
+type callsBuilder struct {
+	names        fakeMethodNames
+	methodConfig *astgen.MethodConfig
+	method       *astgen.Method
+}
+
+func newCallsBuilder(structName string, names fakeMethodNames, methodConfig *astgen.MethodConfig) *callsBuilder {
+	return &callsBuilder{
+		names:        names,
+		methodConfig: methodConfig,
+		method:       astgen.NewMethod(names.method+"Calls", receiverName, structName),
+	}
+}
+
+// Build builds a method that replaces the stub of the method:
+//   func (fake *FakeService) DoWorkCalls(stub func(int) (string, error)) {
+//     fake.doWorkMutex.Lock()
+//     defer fake.doWorkMutex.Unlock()
+//     fake.DoWorkStub = stub
+//   }
+func (b *callsBuilder) Build() ast.Decl {
+	b.method.SetDoc(b.methodConfig.DeprecatedDoc(fmt.Sprintf("%sCalls makes the calls to %s return the results of the stub.", b.names.method, b.names.method)))
+	b.method.SetType(&ast.FuncType{
+		Params: &ast.FieldList{
+			List: []*ast.Field{
+				{
+					Names: []*ast.Ident{ast.NewIdent("stub")},
+					Type:  stubFuncType(b.methodConfig),
+				},
+			},
+		},
+	})
+	b.method.AddStatement(mutexCallStmt(b.names.mutex, "Lock"))
+	b.method.AddStatement(deferredMutexCallStmt(b.names.mutex, "Unlock"))
+	b.method.AddStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{fieldSelector(b.names.stub)},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{ast.NewIdent("stub")},
+	})
+	return b.method.Build()
+}
+
+type argsForCallBuilder struct {
+	names        fakeMethodNames
+	methodConfig *astgen.MethodConfig
+	method       *astgen.Method
+}
+
+func newArgsForCallBuilder(structName string, names fakeMethodNames, methodConfig *astgen.MethodConfig) *argsForCallBuilder {
+	return &argsForCallBuilder{
+		names:        names,
+		methodConfig: methodConfig,
+		method:       astgen.NewMethod(names.method+"ArgsForCall", receiverName, structName),
+	}
+}
+
+// Build builds a method that returns the arguments of the i-th call:
+//   func (fake *FakeService) DoWorkArgsForCall(i int) (int, []string) {
+//     fake.doWorkMutex.RLock()
+//     defer fake.doWorkMutex.RUnlock()
+//     args := fake.doWorkArgsForCall[i]
+//     return args.arg1, args.arg2
+//   }
+func (b *argsForCallBuilder) Build() ast.Decl {
+	b.method.SetDoc(b.methodConfig.DeprecatedDoc(fmt.Sprintf("%sArgsForCall returns the arguments of the i-th call to %s.", b.names.method, b.names.method)))
+	b.method.SetType(&ast.FuncType{
+		Params: &ast.FieldList{
+			List: []*ast.Field{
+				{
+					Names: []*ast.Ident{ast.NewIdent("i")},
+					Type:  ast.NewIdent("int"),
+				},
+			},
+		},
+		Results: &ast.FieldList{
+			List: transformation.FieldsAsAnonymous(transformation.FieldsWithoutEllipsis(b.methodConfig.MethodParams)),
+		},
+	})
+	b.method.AddStatement(mutexCallStmt(b.names.mutex, "RLock"))
+	b.method.AddStatement(deferredMutexCallStmt(b.names.mutex, "RUnlock"))
+	b.method.AddStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{ast.NewIdent("args")},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			&ast.IndexExpr{
+				X:     fieldSelector(b.names.argsForCall),
+				Index: ast.NewIdent("i"),
+			},
+		},
+	})
+	var results []ast.Expr
+	for _, param := range b.methodConfig.MethodParams {
+		results = append(results, &ast.SelectorExpr{
+			X:   ast.NewIdent("args"),
+			Sel: ast.NewIdent(param.Names[0].String()),
+		})
+	}
+	b.method.AddStatement(&ast.ReturnStmt{Results: results})
+	return b.method.Build()
+}
+
+type returnsBuilder struct {
+	names        fakeMethodNames
+	methodConfig *astgen.MethodConfig
+	method       *astgen.Method
+}
+
+func newReturnsBuilder(structName string, names fakeMethodNames, methodConfig *astgen.MethodConfig) *returnsBuilder {
+	return &returnsBuilder{
+		names:        names,
+		methodConfig: methodConfig,
+		method:       astgen.NewMethod(names.method+"Returns", receiverName, structName),
+	}
+}
+
+// Build builds a method that configures the results returned by the method
+// and removes any previously set stub:
+//   func (fake *FakeService) DoWorkReturns(result1 string, result2 error) {
+//     fake.doWorkMutex.Lock()
+//     defer fake.doWorkMutex.Unlock()
+//     fake.DoWorkStub = nil
+//     fake.doWorkReturns = struct{...}{result1, result2}
+//   }
+func (b *returnsBuilder) Build() ast.Decl {
+	b.method.SetDoc(b.methodConfig.DeprecatedDoc(fmt.Sprintf("%sReturns makes the calls to %s return the specified results.", b.names.method, b.names.method)))
+	b.method.SetType(&ast.FuncType{
+		Params: &ast.FieldList{
+			List: b.methodConfig.MethodResults,
+		},
+	})
+	b.method.AddStatement(mutexCallStmt(b.names.mutex, "Lock"))
+	b.method.AddStatement(deferredMutexCallStmt(b.names.mutex, "Unlock"))
+	b.method.AddStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{fieldSelector(b.names.stub)},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{ast.NewIdent("nil")},
+	})
+	b.method.AddStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{fieldSelector(b.names.returns)},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{
+			&ast.CompositeLit{
+				Type: returnsStructType(b.methodConfig),
+				Elts: fieldNames(b.methodConfig.MethodResults),
+			},
+		},
+	})
+	return b.method.Build()
+}
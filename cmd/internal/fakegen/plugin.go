@@ -0,0 +1,26 @@
+package fakegen
+
+import (
+	"fmt"
+
+	"github.com/Bo0mer/gentools/pkg/plugin"
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+// Plugin generates fake implementations of interfaces.
+var Plugin = plugin.Plugin{
+	Name:          "fakegen",
+	Description:   "fake",
+	PackageSuffix: "fakes",
+	FileName: func(cfg plugin.Config) string {
+		return fmt.Sprintf("fake_%s.go", transformation.ToSnakeCase(cfg.InterfaceName))
+	},
+	NewModel: func(cfg plugin.Config) (plugin.Model, error) {
+		typeName := fmt.Sprintf("Fake%s", cfg.InterfaceName)
+		return newModel(cfg.InterfacePath, cfg.InterfaceName, typeName, cfg.TargetPackage), nil
+	},
+}
+
+func init() {
+	plugin.Register(Plugin)
+}
@@ -0,0 +1,88 @@
+package fakegen
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Bo0mer/gentools/pkg/plugin"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// TestGenerateGolden compares the generated fakes with the golden files in
+// testdata/golden/NAME. Run the test with -update to rewrite them.
+func TestGenerateGolden(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		patterns []string
+	}{
+		// The parameters of Find are named like the locals of the fake.
+		{"service", "service", []string{"Service"}},
+		{"generic", "service", []string{"Cache"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The target package has to be in the module, so that its
+			// import path can be resolved.
+			target, err := os.MkdirTemp("testdata", "fakes")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(target)
+			if err := os.WriteFile(filepath.Join(target, "doc.go"), []byte("package fakes\n"), 0666); err != nil {
+				t.Fatal(err)
+			}
+
+			opts := plugin.Options{
+				Selection: plugin.Selection{Patterns: test.patterns},
+				Target:    target,
+			}
+			results, err := plugin.Generate(Plugin, filepath.Join("testdata", test.source), opts)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			dir := filepath.Join("testdata", "golden", test.name)
+			if *update {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.MkdirAll(dir, 0777); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, result := range results {
+				src, err := os.ReadFile(result.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				golden := filepath.Join(dir, filepath.Base(result.Path)+".golden")
+				if *update {
+					if err := os.WriteFile(golden, src, 0666); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("error reading golden file: %v", err)
+				}
+				if !bytes.Equal(src, want) {
+					t.Errorf("generated %s differs from %s:\n%s", filepath.Base(result.Path), golden, src)
+				}
+			}
+			goldens, err := filepath.Glob(filepath.Join(dir, "*.golden"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(goldens) != len(results) {
+				t.Errorf("generated %d files, want %d", len(results), len(goldens))
+			}
+		})
+	}
+}
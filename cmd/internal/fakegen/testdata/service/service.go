@@ -0,0 +1,31 @@
+// Package service declares interfaces to be faked.
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// User is a user of the service.
+type User struct {
+	ID string
+}
+
+// Service manages users.
+type Service interface {
+	// Get returns the user with the id.
+	Get(ctx context.Context, id string) (*User, error)
+	Put(ctx context.Context, users ...*User) error
+	Reader() io.Reader
+	// Find takes parameters named like the locals of the fake.
+	Find(fake, stub string, returns int) (users []User, err error)
+	Close()
+}
+
+// Cache caches values by their keys. The module predates Go 1.18, so the
+// constraints cannot be comparable or any.
+type Cache[K fmt.Stringer, V interface{}] interface {
+	Get(key K) (V, bool)
+	Set(key K, value V)
+}
@@ -0,0 +1,323 @@
+package interceptgen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/Bo0mer/gentools/pkg/astgen"
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+type model struct {
+	fileBuilder *astgen.File
+	structName  string
+
+	contextPackageAlias string
+}
+
+func newModel(interfacePath, interfaceName, structName, targetPkg string) *model {
+	file := astgen.NewFile(targetPkg)
+	strct := astgen.NewStruct(structName)
+	file.AppendDeclaration(strct)
+
+	m := &model{
+		fileBuilder: file,
+		structName:  structName,
+	}
+	sourcePackageAlias := m.AddImport("", interfacePath)
+	interceptPackageAlias := m.AddImport("", "github.com/Bo0mer/gentools/pkg/middleware/intercept")
+	m.contextPackageAlias = m.AddImport("", "context")
+
+	file.AppendDeclaration(astgen.NewInterfaceAssertion(sourcePackageAlias, interfaceName, structName))
+
+	constructorBuilder := newConstructorBuilder(interceptPackageAlias, sourcePackageAlias, interfaceName, structName)
+	file.AppendDeclaration(constructorBuilder)
+
+	strct.AddField("next", sourcePackageAlias, interfaceName)
+	strct.AddField("interceptor", interceptPackageAlias, "Interceptor")
+
+	return m
+}
+
+func (m *model) Build() *ast.File {
+	return m.fileBuilder.Build()
+}
+
+func (m *model) AddImport(pkgName, location string) string {
+	return m.fileBuilder.AddImport(pkgName, location)
+}
+
+func (m *model) AddMethod(method *astgen.MethodConfig) error {
+	mmb := newInterceptingMethodBuilder(m.structName, method, m.contextPackageAlias, m.fileBuilder.MethodScope(method, "m"))
+	m.fileBuilder.AppendDeclaration(mmb)
+	return nil
+}
+
+type constructorBuilder struct {
+	interceptPackageAlias string
+	interfacePackageName  string
+	interfaceName         string
+	structName            string
+}
+
+func newConstructorBuilder(interceptPackageAlias, packageName, interfaceName, structName string) *constructorBuilder {
+	return &constructorBuilder{
+		interceptPackageAlias: interceptPackageAlias,
+		interfacePackageName:  packageName,
+		interfaceName:         interfaceName,
+		structName:            structName,
+	}
+}
+
+func (c *constructorBuilder) Build() ast.Decl {
+	funcBody := &ast.BlockStmt{
+		List: []ast.Stmt{
+			&ast.ReturnStmt{
+				Results: []ast.Expr{
+					&ast.UnaryExpr{
+						Op: token.AND,
+						X: &ast.CompositeLit{
+							Type: ast.NewIdent(c.structName),
+							Elts: []ast.Expr{
+								ast.NewIdent("next"),
+								ast.NewIdent("interceptor"),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	funcName := fmt.Sprintf("NewIntercepting%s", c.interfaceName)
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{
+			List: []*ast.Comment{
+				{
+					Text: fmt.Sprintf("// %s creates new intercepting middleware.", funcName),
+				},
+			},
+		},
+		Name: ast.NewIdent(funcName),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("next")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interfacePackageName),
+							Sel: ast.NewIdent(c.interfaceName),
+						},
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("interceptor")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interceptPackageAlias),
+							Sel: ast.NewIdent("Interceptor"),
+						},
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interfacePackageName),
+							Sel: ast.NewIdent(c.interfaceName),
+						},
+					},
+				},
+			},
+		},
+		Body: funcBody,
+	}
+}
+
+// interceptingMethodBuilder is responsible for creating a method that
+// implements the original method from the interface and invokes the
+// interceptor around the call.
+type interceptingMethodBuilder struct {
+	methodConfig        *astgen.MethodConfig
+	method              *astgen.Method
+	contextPackageAlias string
+	scope               *astgen.Scope
+}
+
+func newInterceptingMethodBuilder(structName string, methodConfig *astgen.MethodConfig, contextPackageAlias string, scope *astgen.Scope) *interceptingMethodBuilder {
+	method := astgen.NewMethod(methodConfig.MethodName, "m", structName)
+
+	return &interceptingMethodBuilder{
+		methodConfig:        methodConfig,
+		method:              method,
+		contextPackageAlias: contextPackageAlias,
+		scope:               scope,
+	}
+}
+
+func (b *interceptingMethodBuilder) Build() ast.Decl {
+	b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf("%s calls the wrapped method, invoking the interceptor before and after\nthe call.", b.methodConfig.MethodName)))
+	b.method.SetType(&ast.FuncType{
+		Params: &ast.FieldList{
+			List: b.methodConfig.MethodParams,
+		},
+		Results: &ast.FieldList{
+			List: transformation.FieldsAsAnonymous(b.methodConfig.MethodResults),
+		},
+	})
+
+	// Invoke the interceptor before the call, replacing the context of the
+	// call, if any:
+	//   arg1 = m.interceptor.Before(arg1, "Method", []interface{}{arg2, arg3})
+	// or intercepting the call with a background context otherwise:
+	//   _ctx := m.interceptor.Before(context.Background(), "Method", []interface{}{arg1})
+	var ctx *ast.Ident
+	var parentCtx ast.Expr = &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent(b.contextPackageAlias),
+			Sel: ast.NewIdent("Background"),
+		},
+	}
+	tok := token.DEFINE
+	params := b.methodConfig.MethodParams
+	if ctxArgName, ok := b.methodConfig.ContextParamName(b.contextPackageAlias); ok {
+		ctx = ast.NewIdent(ctxArgName)
+		parentCtx = ctx
+		tok = token.ASSIGN
+		params = params[1:]
+	} else {
+		ctx = ast.NewIdent(b.scope.Name("_ctx"))
+	}
+	var args []ast.Expr
+	for _, param := range params {
+		args = append(args, ast.NewIdent(param.Names[0].String()))
+	}
+	b.method.AddStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{ctx},
+		Tok: tok,
+		Rhs: []ast.Expr{
+			&ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   interceptorSelector(),
+					Sel: ast.NewIdent("Before"),
+				},
+				Args: []ast.Expr{parentCtx, methodNameLit(b.methodConfig), interfaceSlice(args)},
+			},
+		},
+	})
+
+	// Add method invocation:
+	//   result1, result2 := m.next.Method(arg1, arg2)
+	methodInvocation := astgen.NewMethodInvocation(b.methodConfig)
+	methodInvocation.SetReceiver(&ast.SelectorExpr{
+		X:   ast.NewIdent("m"), // receiver name
+		Sel: ast.NewIdent("next"),
+	})
+	b.method.AddStatement(methodInvocation.Build())
+
+	// Invoke the interceptor after the call:
+	//   m.interceptor.After(arg1, "Method", []interface{}{result1, result2}, result2)
+	var results []ast.Expr
+	for _, result := range b.methodConfig.MethodResults {
+		results = append(results, ast.NewIdent(result.Names[0].String()))
+	}
+	errorArg, selectErrorStmts := b.errorArg()
+	b.method.AddStatements(selectErrorStmts)
+	b.method.AddStatement(&ast.ExprStmt{
+		X: &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   interceptorSelector(),
+				Sel: ast.NewIdent("After"),
+			},
+			Args: []ast.Expr{ctx, methodNameLit(b.methodConfig), interfaceSlice(results), errorArg},
+		},
+	})
+
+	// Add return statement
+	//   return result1, result2
+	b.method.AddStatement(astgen.NewReturnResults(b.methodConfig).Build())
+
+	return b.method.Build()
+}
+
+// errorArg returns the error which should be passed to the interceptor,
+// along with the statements which select it. That is the error result itself
+// if it is of type error, or the first non-nil error result, stored in an
+// error variable so that nil pointers to error implementations are passed as
+// nil errors:
+//   var _err error
+//   if result2 != nil {
+//     _err = result2
+//   }
+func (b *interceptingMethodBuilder) errorArg() (ast.Expr, []ast.Stmt) {
+	errorResults := b.methodConfig.ErrorResults
+	if len(errorResults) == 0 {
+		return ast.NewIdent("nil"), nil
+	}
+	if len(errorResults) == 1 {
+		if ident, ok := errorResults[0].Type.(*ast.Ident); ok && ident.Name == "error" {
+			return ast.NewIdent(errorResults[0].Names[0].String()), nil
+		}
+	}
+
+	errVarName := b.scope.Name("_err")
+	stmts := []ast.Stmt{
+		&ast.DeclStmt{
+			Decl: &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{
+					&ast.ValueSpec{
+						Names: []*ast.Ident{ast.NewIdent(errVarName)},
+						Type:  ast.NewIdent("error"),
+					},
+				},
+			},
+		},
+	}
+	for i := len(errorResults) - 1; i >= 0; i-- {
+		name := errorResults[i].Names[0].String()
+		stmts = append(stmts, &ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X:  ast.NewIdent(name),
+				Op: token.NEQ,
+				Y:  ast.NewIdent("nil"),
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{ast.NewIdent(errVarName)},
+						Tok: token.ASSIGN,
+						Rhs: []ast.Expr{ast.NewIdent(name)},
+					},
+				},
+			},
+		})
+	}
+	return ast.NewIdent(errVarName), stmts
+}
+
+func interceptorSelector() *ast.SelectorExpr {
+	return &ast.SelectorExpr{
+		X:   ast.NewIdent("m"), // receiver name
+		Sel: ast.NewIdent("interceptor"),
+	}
+}
+
+func methodNameLit(method *astgen.MethodConfig) ast.Expr {
+	return &ast.BasicLit{Kind: token.STRING, Value: fmt.Sprintf("%q", method.MethodName)}
+}
+
+// interfaceSlice builds a slice literal holding the specified values, or nil
+// if there are none:
+//   []interface{}{arg2, arg3}
+func interfaceSlice(values []ast.Expr) ast.Expr {
+	if len(values) == 0 {
+		return ast.NewIdent("nil")
+	}
+	return &ast.CompositeLit{
+		Type: &ast.ArrayType{
+			Elt: &ast.InterfaceType{Methods: &ast.FieldList{Opening: 1, Closing: 1}},
+		},
+		Elts: values,
+	}
+}
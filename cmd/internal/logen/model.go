@@ -0,0 +1,220 @@
+package logen
+
+import (
+	"github.com/Bo0mer/gentools/pkg/transformation"
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/Bo0mer/gentools/cmd/internal/logging"
+	"github.com/Bo0mer/gentools/pkg/astgen"
+)
+
+type model struct {
+	fileBuilder *astgen.File
+	structName  string
+	strct       *astgen.Struct
+
+	contextPackageAlias string
+}
+
+func newModel(interfacePath, interfaceName, structName, targetPkg string) *model {
+	file := astgen.NewFile(targetPkg)
+	strct := astgen.NewStruct(structName)
+	file.AppendDeclaration(strct)
+
+	m := &model{
+		fileBuilder: file,
+		structName:  structName,
+		strct:       strct,
+	}
+	sourcePackageAlias := m.AddImport("", interfacePath)
+	logPackageAlias := m.AddImport("", "github.com/go-kit/kit/log")
+	m.contextPackageAlias = m.AddImport("", "context")
+
+	file.AppendDeclaration(astgen.NewInterfaceAssertion(sourcePackageAlias, interfaceName, structName))
+
+	constructorBuilder := newConstructorBuilder(logPackageAlias, sourcePackageAlias, interfaceName, m.contextPackageAlias)
+	file.AppendDeclaration(constructorBuilder)
+
+	strct.AddField("next", sourcePackageAlias, interfaceName)
+	strct.AddField("logger", logPackageAlias, "Logger")
+	strct.AddFieldWithType("fields", logging.FieldsFuncType(m.contextPackageAlias))
+
+	return m
+}
+
+func (m *model) Build() *ast.File {
+	return m.fileBuilder.Build()
+}
+
+func (m *model) AddImport(pkgName, location string) string {
+	return m.fileBuilder.AddImport(pkgName, location)
+}
+
+func (m *model) AddMethod(method *astgen.MethodConfig) error {
+	mmb := NewLoggingMethodBuilder(m.structName, method, m.contextPackageAlias, m.fileBuilder.MethodScope(method, "m"))
+
+	m.fileBuilder.AppendDeclaration(mmb)
+	return nil
+}
+
+func (m *model) resolveInterfaceType(location, name string) *ast.SelectorExpr {
+	alias := m.AddImport("", location)
+	return &ast.SelectorExpr{
+		X:   ast.NewIdent(alias),
+		Sel: ast.NewIdent(name),
+	}
+}
+
+type constructorBuilder struct {
+	logPackageName       string
+	interfacePackageName string
+	interfaceName        string
+	contextPackageName   string
+}
+
+func newConstructorBuilder(logPackageName, packageName, interfaceName, contextPackageName string) *constructorBuilder {
+	return &constructorBuilder{
+		logPackageName:       logPackageName,
+		interfacePackageName: packageName,
+		interfaceName:        interfaceName,
+		contextPackageName:   contextPackageName,
+	}
+}
+
+func (c *constructorBuilder) Build() ast.Decl {
+	funcBody := &ast.BlockStmt{
+		List: []ast.Stmt{
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{ast.NewIdent("f")},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{&ast.CompositeLit{
+					Type: logging.FieldsFuncType(c.contextPackageName),
+					Elts: []ast.Expr{ast.NewIdent("return nil")},
+				}},
+			},
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{
+					X: &ast.CallExpr{
+						Fun:  ast.NewIdent("len"),
+						Args: []ast.Expr{ast.NewIdent("fields")},
+					},
+					Op: token.GTR,
+					Y:  &ast.BasicLit{Kind: token.INT, Value: "0"},
+				},
+				Body: &ast.BlockStmt{List: []ast.Stmt{
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{ast.NewIdent("f")},
+						Tok: token.ASSIGN,
+						Rhs: []ast.Expr{&ast.IndexExpr{X: ast.NewIdent("fields"), Index: &ast.BasicLit{Kind: token.INT, Value: "0"}}},
+					},
+				}},
+			},
+			&ast.ReturnStmt{
+				Results: []ast.Expr{
+					// TODO(borshukov): Find a better way to do this.
+					ast.NewIdent(fmt.Sprintf("&errorLogging%s{next: next, logger: logger, fields: f}", c.interfaceName)),
+				},
+			},
+		},
+	}
+
+	funcName := fmt.Sprintf("NewErrorLogging%s", c.interfaceName)
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{
+			List: []*ast.Comment{&ast.Comment{
+				Text: fmt.Sprintf("// %s creates new error logging middleware.", funcName),
+			}},
+		},
+		Name: ast.NewIdent(funcName),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					&ast.Field{
+						Names: []*ast.Ident{ast.NewIdent("next")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interfacePackageName),
+							Sel: ast.NewIdent(c.interfaceName),
+						},
+					},
+					&ast.Field{
+						Names: []*ast.Ident{ast.NewIdent("logger")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.logPackageName),
+							Sel: ast.NewIdent("Logger"),
+						},
+					},
+					&ast.Field{
+						Names: []*ast.Ident{ast.NewIdent("fields")},
+						Type:  &ast.Ellipsis{Elt: logging.FieldsFuncType(c.contextPackageName)},
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					&ast.Field{
+						Names: []*ast.Ident{ast.NewIdent("")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interfacePackageName),
+							Sel: ast.NewIdent(c.interfaceName),
+						},
+					},
+				},
+			},
+		},
+		Body: funcBody,
+	}
+}
+
+type LoggingMethodBuilder struct {
+	methodConfig        *astgen.MethodConfig
+	method              *astgen.Method
+	contextPackageAlias string
+	scope               *astgen.Scope
+}
+
+func NewLoggingMethodBuilder(structName string, methodConfig *astgen.MethodConfig, contextPackageAlias string, scope *astgen.Scope) *LoggingMethodBuilder {
+	method := astgen.NewMethod(methodConfig.MethodName, "m", structName)
+
+	return &LoggingMethodBuilder{
+		methodConfig:        methodConfig,
+		method:              method,
+		contextPackageAlias: contextPackageAlias,
+		scope:               scope,
+	}
+}
+func (b *LoggingMethodBuilder) Build() ast.Decl {
+	b.method.SetType(&ast.FuncType{
+		Params: &ast.FieldList{
+			List: b.methodConfig.MethodParams,
+		},
+		Results: &ast.FieldList{
+			List: transformation.FieldsAsAnonymous(b.methodConfig.MethodResults),
+		},
+	})
+
+	// Add method invocation:
+	//   result1, result2 := m.next.Method(arg1, arg2)
+	methodInvocation := astgen.NewMethodInvocation(b.methodConfig)
+	methodInvocation.SetReceiver(&ast.SelectorExpr{
+		X:   ast.NewIdent("m"), // receiver name
+		Sel: ast.NewIdent("next"),
+	})
+	b.method.AddStatement(methodInvocation.Build())
+
+	// Log if the call has failed.
+	summary := "%s calls the wrapped method."
+	if b.methodConfig.FailureCondition != nil {
+		summary = "%s calls the wrapped method and logs its failures."
+		b.method.AddStatement(logging.NewFailureLog(b.methodConfig, b.contextPackageAlias, b.scope).BuildConditional())
+	}
+
+	// Add return statement
+	//   return result1, result2
+	returnResults := astgen.NewReturnResults(b.methodConfig)
+	b.method.AddStatement(returnResults.Build())
+
+	b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf(summary, b.methodConfig.MethodName)))
+	return b.method.Build()
+}
@@ -0,0 +1,25 @@
+package logen
+
+import (
+	"fmt"
+
+	"github.com/Bo0mer/gentools/pkg/plugin"
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+// Plugin generates logging implementations of interfaces.
+var Plugin = plugin.Plugin{
+	Name:        "logen",
+	Description: "logging",
+	FileName: func(cfg plugin.Config) string {
+		return fmt.Sprintf("logging_%s.go", transformation.ToSnakeCase(cfg.InterfaceName))
+	},
+	NewModel: func(cfg plugin.Config) (plugin.Model, error) {
+		typeName := fmt.Sprintf("errorLogging%s", cfg.InterfaceName)
+		return newModel(cfg.InterfacePath, cfg.InterfaceName, typeName, cfg.TargetPackage), nil
+	},
+}
+
+func init() {
+	plugin.Register(Plugin)
+}
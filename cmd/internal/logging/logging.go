@@ -0,0 +1,295 @@
+// Package logging provides the builders of the go-kit logging statements
+// shared by the generators.
+package logging
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/Bo0mer/gentools/pkg/astgen"
+)
+
+// FailureLog builds the statements which log a failed call to a method,
+// along with the additional fields provided by the fields func of the
+// middleware if the method accepts a context.
+type FailureLog struct {
+	method              *astgen.MethodConfig
+	contextPackageAlias string
+	scope               *astgen.Scope
+	fields              []ast.Expr
+
+	// fieldsVarName is the name of the variable holding the log fields.
+	fieldsVarName string
+}
+
+// NewFailureLog returns a new failure log of the method, whose variables are
+// allocated in the scope of the method.
+func NewFailureLog(method *astgen.MethodConfig, contextPackageAlias string, scope *astgen.Scope) *FailureLog {
+	return &FailureLog{
+		method:              method,
+		contextPackageAlias: contextPackageAlias,
+		scope:               scope,
+	}
+}
+
+// AddFields adds fields, as key and value expressions, which are logged
+// right after the description of the failure.
+func (l *FailureLog) AddFields(fields ...ast.Expr) {
+	l.fields = append(l.fields, fields...)
+}
+
+// errorFields returns the log fields describing the failure which can be
+// built unconditionally, and the statements which append the fields of error
+// results that might be nil even though the call has failed.
+func (l *FailureLog) errorFields() ([]ast.Expr, []ast.Stmt) {
+	errorResults := l.method.ErrorResults
+	if len(errorResults) == 0 {
+		return []ast.Expr{
+			&ast.BasicLit{Kind: token.STRING, Value: `"error"`},
+			&ast.BasicLit{Kind: token.STRING, Value: `"operation failed"`},
+		}, nil
+	}
+
+	errorField := func(name string) []ast.Expr {
+		return []ast.Expr{
+			&ast.BasicLit{Kind: token.STRING, Value: `"error"`},
+			&ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   ast.NewIdent(name),
+					Sel: ast.NewIdent("Error"),
+				},
+			},
+		}
+	}
+
+	// The default failure condition of a method with a single error result
+	// guarantees that the error is not nil.
+	if len(errorResults) == 1 && !l.method.HasCustomFailure() {
+		return errorField(errorResults[0].Names[0].Name), nil
+	}
+
+	var stmts []ast.Stmt
+	for _, result := range errorResults {
+		name := result.Names[0].Name
+		// if [name] != nil {
+		//   _fields = append(_fields, "error", [name].Error())
+		// }
+		stmts = append(stmts, &ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X:  ast.NewIdent(name),
+				Op: token.NEQ,
+				Y:  ast.NewIdent("nil"),
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{ast.NewIdent(l.fieldsVarName)},
+						Tok: token.ASSIGN,
+						Rhs: []ast.Expr{
+							&ast.CallExpr{
+								Fun:  ast.NewIdent("append"),
+								Args: append([]ast.Expr{ast.NewIdent(l.fieldsVarName)}, errorField(name)...),
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+	return nil, stmts
+}
+
+// fieldsErrorArg returns the error which should be passed to the fields
+// func, along with the statements which select it. That is the first non-nil
+// error result, stored in an error variable so that nil pointers to error
+// implementations are passed as nil errors.
+func (l *FailureLog) fieldsErrorArg() (ast.Expr, []ast.Stmt) {
+	errorResults := l.method.ErrorResults
+	if len(errorResults) == 0 {
+		return ast.NewIdent("nil"), nil
+	}
+	if len(errorResults) == 1 && !l.method.HasCustomFailure() {
+		return ast.NewIdent(errorResults[0].Names[0].Name), nil
+	}
+
+	// var _err error
+	errVarName := l.scope.Name("_err")
+	stmts := []ast.Stmt{
+		&ast.DeclStmt{
+			Decl: &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{
+					&ast.ValueSpec{
+						Names: []*ast.Ident{ast.NewIdent(errVarName)},
+						Type:  ast.NewIdent("error"),
+					},
+				},
+			},
+		},
+	}
+	for i := len(errorResults) - 1; i >= 0; i-- {
+		name := errorResults[i].Names[0].Name
+		// if [name] != nil {
+		//   _err = [name]
+		// }
+		stmts = append(stmts, &ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X:  ast.NewIdent(name),
+				Op: token.NEQ,
+				Y:  ast.NewIdent("nil"),
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{ast.NewIdent(errVarName)},
+						Tok: token.ASSIGN,
+						Rhs: []ast.Expr{ast.NewIdent(name)},
+					},
+				},
+			},
+		})
+	}
+	return ast.NewIdent(errVarName), stmts
+}
+
+// Build builds the statements which log the failure:
+//   _fields := []interface{}{"method", "Method", "error", result2.Error()}
+//   _more := m.fields(ctx, result2)
+//   if len(_more) > 0 {
+//     _fields = append(_fields, _more...)
+//   }
+//   m.logger.Log(_fields...)
+func (l *FailureLog) Build() []ast.Stmt {
+	l.fieldsVarName = l.scope.Name("_fields")
+
+	// If the first parameter is context.Context, get additional log
+	// fields.
+	var selectErrorStmts []ast.Stmt
+	var additionalFieldsStmt ast.Stmt = &ast.EmptyStmt{}
+	var appendAdditionalFieldsStmt ast.Stmt = &ast.EmptyStmt{}
+	if ctxArgName, ok := l.method.ContextParamName(l.contextPackageAlias); ok {
+		var errorResult ast.Expr
+		errorResult, selectErrorStmts = l.fieldsErrorArg()
+		callExpr := &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   ast.NewIdent("m"), // receiver name
+				Sel: ast.NewIdent("fields"),
+			},
+			Args: []ast.Expr{ast.NewIdent(ctxArgName), errorResult},
+		}
+
+		moreVarName := l.scope.Name("_more")
+		additionalFieldsStmt = &ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(moreVarName)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				callExpr,
+			},
+		}
+
+		// if len(_more) > 0 {
+
+		appendAdditionalFieldsStmt = &ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X: &ast.CallExpr{
+					Fun:  ast.NewIdent("len"),
+					Args: []ast.Expr{ast.NewIdent(moreVarName)},
+				},
+				Op: token.GTR,
+				Y:  ast.NewIdent("0"),
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					// _fields = append(_fields, _more...)
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{ast.NewIdent(l.fieldsVarName)},
+						Tok: token.ASSIGN,
+						Rhs: []ast.Expr{
+							&ast.CallExpr{
+								Fun:      ast.NewIdent("append"),
+								Args:     []ast.Expr{ast.NewIdent(l.fieldsVarName), ast.NewIdent(moreVarName)},
+								Ellipsis: 1,
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	errorFields, appendErrorFieldsStmts := l.errorFields()
+	assignStmt := &ast.AssignStmt{
+		Lhs: []ast.Expr{ast.NewIdent(l.fieldsVarName)},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			&ast.CompositeLit{
+				Type: &ast.ArrayType{
+					Elt: ast.NewIdent("interface{}"),
+				},
+				Elts: append([]ast.Expr{
+					&ast.BasicLit{Kind: token.STRING, Value: `"method"`},
+					&ast.BasicLit{Kind: token.STRING, Value: fmt.Sprintf("%q", l.method.MethodName)},
+				}, append(errorFields, l.fields...)...),
+			},
+		},
+	}
+
+	callLogExpr := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   &ast.SelectorExpr{X: ast.NewIdent("m"), Sel: ast.NewIdent("logger")},
+			Sel: ast.NewIdent("Log"),
+		},
+		Args: []ast.Expr{
+			ast.NewIdent(l.fieldsVarName),
+		},
+		Ellipsis: 1,
+	}
+
+	body := []ast.Stmt{assignStmt}
+	body = append(body, appendErrorFieldsStmts...)
+	body = append(body, selectErrorStmts...)
+	body = append(body,
+		additionalFieldsStmt,
+		appendAdditionalFieldsStmt,
+		&ast.ExprStmt{X: callLogExpr})
+
+	return body
+}
+
+// BuildConditional builds the statement which logs the failure only if the
+// call has failed:
+//   if [failure condition] {
+//     [log statements]
+//   }
+func (l *FailureLog) BuildConditional() ast.Stmt {
+	return &ast.IfStmt{
+		Cond: l.method.FailureCondition,
+		Body: &ast.BlockStmt{
+			List: l.Build(),
+		},
+	}
+}
+
+// FieldsFuncType returns the type of the func which provides additional log
+// fields based on the context of a call and its error.
+func FieldsFuncType(contextPackageAlias string) ast.Expr {
+	return &ast.FuncType{
+		Params: &ast.FieldList{List: []*ast.Field{
+			&ast.Field{
+				Names: []*ast.Ident{ast.NewIdent("ctx")},
+				Type: &ast.SelectorExpr{
+					X:   ast.NewIdent(contextPackageAlias),
+					Sel: ast.NewIdent("Context"),
+				},
+			},
+			&ast.Field{
+				Names: []*ast.Ident{ast.NewIdent("err")},
+				Type:  ast.NewIdent("error"),
+			},
+		}},
+		Results: &ast.FieldList{List: []*ast.Field{
+			&ast.Field{Type: ast.NewIdent("[]interface{}")},
+		}},
+	}
+}
@@ -0,0 +1,266 @@
+package combined
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/Bo0mer/gentools/cmd/internal/logging"
+	"github.com/Bo0mer/gentools/cmd/internal/tracing"
+	"github.com/Bo0mer/gentools/cmd/internal/mongen/commonbuilders"
+	"github.com/Bo0mer/gentools/cmd/internal/mongen/gokit"
+	"github.com/Bo0mer/gentools/pkg/astgen"
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+type constructorBuilder struct {
+	packageAliases       packageAliases
+	interfacePackageName string
+	interfaceName        string
+	structName           string
+}
+
+func newConstructorBuilder(packageAliases packageAliases, packageName, interfaceName, structName string) *constructorBuilder {
+	return &constructorBuilder{
+		packageAliases:       packageAliases,
+		interfacePackageName: packageName,
+		interfaceName:        interfaceName,
+		structName:           structName,
+	}
+}
+
+// Build builds the constructor, which defaults the fields func like the one
+// of the error logging middleware:
+//   f := func(ctx context.Context, err error) []interface{} { return nil }
+//   if len(fields) > 0 {
+//     f = fields[0]
+//   }
+//   return &observingService{next: next, totalOps: totalOps, ..., fields: f}
+func (c *constructorBuilder) Build() ast.Decl {
+	keyValue := func(key, value string) ast.Expr {
+		return &ast.KeyValueExpr{Key: ast.NewIdent(key), Value: ast.NewIdent(value)}
+	}
+
+	funcBody := &ast.BlockStmt{
+		List: []ast.Stmt{
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{ast.NewIdent("f")},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{&ast.FuncLit{
+					Type: logging.FieldsFuncType(c.packageAliases.contextPkg).(*ast.FuncType),
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.ReturnStmt{Results: []ast.Expr{ast.NewIdent("nil")}},
+						},
+					},
+				}},
+			},
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{
+					X: &ast.CallExpr{
+						Fun:  ast.NewIdent("len"),
+						Args: []ast.Expr{ast.NewIdent("fields")},
+					},
+					Op: token.GTR,
+					Y:  &ast.BasicLit{Kind: token.INT, Value: "0"},
+				},
+				Body: &ast.BlockStmt{List: []ast.Stmt{
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{ast.NewIdent("f")},
+						Tok: token.ASSIGN,
+						Rhs: []ast.Expr{&ast.IndexExpr{X: ast.NewIdent("fields"), Index: &ast.BasicLit{Kind: token.INT, Value: "0"}}},
+					},
+				}},
+			},
+			&ast.ReturnStmt{
+				Results: []ast.Expr{
+					&ast.UnaryExpr{
+						Op: token.AND,
+						X: &ast.CompositeLit{
+							Type: ast.NewIdent(c.structName),
+							Elts: []ast.Expr{
+								keyValue("next", "next"),
+								keyValue(commonbuilders.TotalOpsMetricName, commonbuilders.TotalOpsMetricName),
+								keyValue(commonbuilders.FailedOpsMetricName, commonbuilders.FailedOpsMetricName),
+								keyValue(commonbuilders.OpsDurationMetricName, commonbuilders.OpsDurationMetricName),
+								keyValue("logger", "logger"),
+								keyValue("fields", "f"),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	param := func(name, pkg, typ string) *ast.Field {
+		return &ast.Field{
+			Names: []*ast.Ident{ast.NewIdent(name)},
+			Type: &ast.SelectorExpr{
+				X:   ast.NewIdent(pkg),
+				Sel: ast.NewIdent(typ),
+			},
+		}
+	}
+
+	funcName := fmt.Sprintf("NewObserving%s", c.interfaceName)
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{
+			List: []*ast.Comment{
+				{
+					Text: fmt.Sprintf("// %s creates new monitoring, tracing and error logging middleware.", funcName),
+				},
+			},
+		},
+		Name: ast.NewIdent(funcName),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					param("next", c.interfacePackageName, c.interfaceName),
+					param(commonbuilders.TotalOpsMetricName, c.packageAliases.metricsPkg, "Counter"),
+					param(commonbuilders.FailedOpsMetricName, c.packageAliases.metricsPkg, "Counter"),
+					param(commonbuilders.OpsDurationMetricName, c.packageAliases.metricsPkg, "Histogram"),
+					param("logger", c.packageAliases.logPkg, "Logger"),
+					{
+						Names: []*ast.Ident{ast.NewIdent("fields")},
+						Type:  &ast.Ellipsis{Elt: logging.FieldsFuncType(c.packageAliases.contextPkg)},
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interfacePackageName),
+							Sel: ast.NewIdent(c.interfaceName),
+						},
+					},
+				},
+			},
+		},
+		Body: funcBody,
+	}
+}
+
+// combinedMethodBuilder is responsible for creating a method that implements
+// the original method from the interface and does all the measurement,
+// tracing and error logging logic in a single body.
+type combinedMethodBuilder struct {
+	fullMethodName string
+	methodConfig   *astgen.MethodConfig
+	method         *astgen.Method
+	packageAliases packageAliases
+
+	totalOps    *ast.SelectorExpr // selector for the struct member
+	failedOps   *ast.SelectorExpr // selector for the struct member
+	opsDuration *ast.SelectorExpr // selector for the struct member
+
+	scope *astgen.Scope
+}
+
+func newCombinedMethodBuilder(structName string, methodConfig *astgen.MethodConfig, packageAliases packageAliases, fullMethodName string, scope *astgen.Scope) *combinedMethodBuilder {
+	method := astgen.NewMethod(methodConfig.MethodName, "m", structName)
+
+	selexpr := func(fieldName string) *ast.SelectorExpr {
+		return &ast.SelectorExpr{
+			X:   ast.NewIdent("m"),
+			Sel: ast.NewIdent(fieldName),
+		}
+	}
+
+	return &combinedMethodBuilder{
+		fullMethodName: fullMethodName,
+		methodConfig:   methodConfig,
+		method:         method,
+		packageAliases: packageAliases,
+		totalOps:       selexpr(commonbuilders.TotalOpsMetricName),
+		failedOps:      selexpr(commonbuilders.FailedOpsMetricName),
+		opsDuration:    selexpr(commonbuilders.OpsDurationMetricName),
+		scope:          scope,
+	}
+}
+
+func (b *combinedMethodBuilder) Build() ast.Decl {
+	b.method.SetType(&ast.FuncType{
+		Params: &ast.FieldList{
+			List: b.methodConfig.MethodParams,
+		},
+		Results: &ast.FieldList{
+			List: transformation.FieldsAsAnonymous(b.methodConfig.MethodResults),
+		},
+	})
+
+	// If the first parameter is context, add tracing call.
+	//   ctx, _span := trace.StartSpan(ctx, "github.com/pkg.Component.Method")
+	//   defer _span.End()
+	var spanVarName string
+	ctxArgName, traced := b.methodConfig.ContextParamName(b.packageAliases.contextPkg)
+	if traced {
+		spanVarName = b.scope.Name(tracing.SpanVarName)
+		b.method.AddStatement(tracing.StartSpan(b.packageAliases.tracePkg, ctxArgName, spanVarName, b.fullMethodName))
+		b.method.AddStatement(tracing.EndSpan(spanVarName))
+	}
+	summary := "%s calls the wrapped method and records its metrics."
+	switch {
+	case traced && b.methodConfig.FailureCondition != nil:
+		summary = "%s calls the wrapped method in a new span, records its metrics and logs its failures."
+	case traced:
+		summary = "%s calls the wrapped method in a new span and records its metrics."
+	case b.methodConfig.FailureCondition != nil:
+		summary = "%s calls the wrapped method, records its metrics and logs its failures."
+	}
+	b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf(summary, b.methodConfig.MethodName)))
+
+	// Add increase total operations statement
+	//   m.totalOps.With("operation", "method").Add(1)
+	increaseTotalOps := gokit.NewCounterAddAction(b.totalOps, b.methodConfig.MethodName)
+	b.method.AddStatement(increaseTotalOps.Build())
+
+	// Add statement to capture current time
+	//   _start := time.Now()
+	startVarName := b.scope.Name(commonbuilders.StartVarName)
+	b.method.AddStatement(commonbuilders.RecordStartTime(b.packageAliases.timePkg, startVarName).Build())
+
+	// Add method invocation:
+	//   result1, result2 := m.next.Method(arg1, arg2)
+	methodInvocation := astgen.NewMethodInvocation(b.methodConfig)
+	methodInvocation.SetReceiver(&ast.SelectorExpr{
+		X:   ast.NewIdent("m"), // receiver name
+		Sel: ast.NewIdent("next"),
+	})
+	b.method.AddStatement(methodInvocation.Build())
+
+	// Record operation duration
+	//   m.opsDuration.With("operation", "method").Observe(time.Since(_start).Seconds())
+	b.method.AddStatement(gokit.NewRecordOpDuraton(b.packageAliases.timePkg, startVarName, b.opsDuration, b.methodConfig.MethodName).Build())
+
+	// Record the failure, if the call has failed:
+	//   if [failure condition] {
+	//     m.failedOps.With("operation", "method").Add(1)
+	//     _span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: result2.Error()})
+	//     [log statements, correlated with the span]
+	//   }
+	if b.methodConfig.FailureCondition != nil {
+		failureStmts := []ast.Stmt{
+			gokit.NewCounterAddAction(b.failedOps, b.methodConfig.MethodName).Build(),
+		}
+		failureLog := logging.NewFailureLog(b.methodConfig, b.packageAliases.contextPkg, b.scope)
+		if traced {
+			failureStmts = append(failureStmts,
+				tracing.SetSpanStatus(b.packageAliases.tracePkg, spanVarName, tracing.FailureMessage(b.methodConfig)))
+			failureLog.AddFields(tracing.CorrelationFields(spanVarName)...)
+		}
+		failureStmts = append(failureStmts, failureLog.Build()...)
+
+		b.method.AddStatement(&ast.IfStmt{
+			Cond: b.methodConfig.FailureCondition,
+			Body: &ast.BlockStmt{List: failureStmts},
+		})
+	}
+
+	// Add return statement
+	//   return result1, result2
+	b.method.AddStatement(astgen.NewReturnResults(b.methodConfig).Build())
+
+	return b.method.Build()
+}
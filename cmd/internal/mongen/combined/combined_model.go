@@ -0,0 +1,85 @@
+package combined
+
+import (
+	"fmt"
+	"go/ast"
+
+	"github.com/Bo0mer/gentools/cmd/internal/logging"
+	"github.com/Bo0mer/gentools/cmd/internal/mongen/commonbuilders"
+	"github.com/Bo0mer/gentools/pkg/astgen"
+)
+
+// packageAliases holds the aliases of all imported packages in the generated source file.
+type packageAliases struct {
+	contextPkg string
+	timePkg    string
+	metricsPkg string
+	logPkg     string
+	tracePkg   string
+}
+
+type combinedModel struct {
+	interfacePath string
+	interfaceName string
+	fileBuilder   *astgen.File
+	structName    string
+
+	packageAliases packageAliases
+}
+
+// NewCombinedModel returns a model of a middleware which records go-kit
+// metrics, opencensus traces and go-kit error logs of all calls at once.
+func NewCombinedModel(interfacePath, interfaceName, structName, targetPkg string) *combinedModel {
+	file := astgen.NewFile(targetPkg)
+	strct := astgen.NewStruct(structName)
+	file.AppendDeclaration(strct)
+
+	m := &combinedModel{
+		interfacePath: interfacePath,
+		interfaceName: interfaceName,
+		fileBuilder:   file,
+		structName:    structName,
+	}
+	sourcePackageAlias := m.AddImport("", interfacePath)
+	m.packageAliases = packageAliases{
+		contextPkg: m.AddImport("", "context"),
+		timePkg:    m.AddImport("", "time"),
+		metricsPkg: m.AddImport("", "github.com/go-kit/kit/metrics"),
+		logPkg:     m.AddImport("", "github.com/go-kit/kit/log"),
+	}
+
+	file.AppendDeclaration(astgen.NewInterfaceAssertion(sourcePackageAlias, interfaceName, structName))
+
+	constructorBuilder := newConstructorBuilder(m.packageAliases, sourcePackageAlias, interfaceName, structName)
+	file.AppendDeclaration(constructorBuilder)
+
+	strct.AddField("next", sourcePackageAlias, interfaceName)
+	strct.AddField(commonbuilders.TotalOpsMetricName, m.packageAliases.metricsPkg, "Counter")
+	strct.AddField(commonbuilders.FailedOpsMetricName, m.packageAliases.metricsPkg, "Counter")
+	strct.AddField(commonbuilders.OpsDurationMetricName, m.packageAliases.metricsPkg, "Histogram")
+	strct.AddField("logger", m.packageAliases.logPkg, "Logger")
+	strct.AddFieldWithType("fields", logging.FieldsFuncType(m.packageAliases.contextPkg))
+
+	return m
+}
+
+func (m *combinedModel) AddImport(pkgName, location string) string {
+	return m.fileBuilder.AddImport(pkgName, location)
+}
+
+func (m *combinedModel) AddMethod(method *astgen.MethodConfig) error {
+	// The trace package is imported by the first traced method, as it would
+	// be left unused by interfaces without any.
+	if _, traced := method.ContextParamName(m.packageAliases.contextPkg); traced && m.packageAliases.tracePkg == "" {
+		m.packageAliases.tracePkg = m.AddImport("", "go.opencensus.io/trace")
+	}
+	fullMethodName := fmt.Sprintf("%s.%s.%s", m.interfacePath, m.interfaceName, method.MethodName)
+	mmb := newCombinedMethodBuilder(m.structName, method, m.packageAliases, fullMethodName, m.fileBuilder.MethodScope(method, "m"))
+
+	m.fileBuilder.AppendDeclaration(mmb)
+	return nil
+}
+
+func (m *combinedModel) Build() *ast.File {
+	return m.fileBuilder.Build()
+}
@@ -0,0 +1,48 @@
+package commonbuilders
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// constructor parameter names
+const (
+	// metric params
+	TotalOpsMetricName    = "totalOps"
+	FailedOpsMetricName   = "failedOps"
+	OpsDurationMetricName = "opsDuration"
+
+	// context decorator param
+	ContextDecoratorFuncName = "ctxFunc"
+)
+
+// StartVarName is the name of the variable holding the start time of a
+// call, unless it is taken in the scope of the method.
+const StartVarName = "_start"
+
+type StartTimeRecorder struct {
+	TimePackageAlias string
+	StartFieldName   string
+}
+
+func RecordStartTime(timePackageAlias, startVarName string) *StartTimeRecorder {
+	return &StartTimeRecorder{TimePackageAlias: timePackageAlias, StartFieldName: startVarName}
+}
+
+// Build builds a statement that records the current timestamp in a new variable.
+func (r StartTimeRecorder) Build() ast.Stmt {
+	callExpr := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent(r.TimePackageAlias),
+			Sel: ast.NewIdent("Now"),
+		},
+	}
+
+	return &ast.AssignStmt{
+		Lhs: []ast.Expr{ast.NewIdent(r.StartFieldName)},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			callExpr,
+		},
+	}
+}
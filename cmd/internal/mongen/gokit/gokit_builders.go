@@ -5,7 +5,7 @@ import (
 	"go/ast"
 	"go/token"
 
-	"github.com/Bo0mer/gentools/cmd/mongen/internal/commonbuilders"
+	"github.com/Bo0mer/gentools/cmd/internal/mongen/commonbuilders"
 	"github.com/Bo0mer/gentools/pkg/astgen"
 	"github.com/Bo0mer/gentools/pkg/transformation"
 )
@@ -105,9 +105,10 @@ type monitoringMethodBuilder struct {
 	opsDuration *ast.SelectorExpr // selector for the struct member
 
 	timePackageAlias string
+	scope            *astgen.Scope
 }
 
-func newMonitoringMethodBuilder(structName string, methodConfig *astgen.MethodConfig) *monitoringMethodBuilder {
+func newMonitoringMethodBuilder(structName string, methodConfig *astgen.MethodConfig, scope *astgen.Scope) *monitoringMethodBuilder {
 	method := astgen.NewMethod(methodConfig.MethodName, "m", structName)
 
 	selexpr := func(fieldName string) *ast.SelectorExpr {
@@ -123,6 +124,7 @@ func newMonitoringMethodBuilder(structName string, methodConfig *astgen.MethodCo
 		totalOps:     selexpr(commonbuilders.TotalOpsMetricName),
 		failedOps:    selexpr(commonbuilders.FailedOpsMetricName),
 		opsDuration:  selexpr(commonbuilders.OpsDurationMetricName),
+		scope:        scope,
 	}
 }
 
@@ -131,6 +133,7 @@ func (b *monitoringMethodBuilder) SetTimePackageAlias(alias string) {
 }
 
 func (b *monitoringMethodBuilder) Build() ast.Decl {
+	b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf("%s calls the wrapped method and records its metrics.", b.methodConfig.MethodName)))
 	b.method.SetType(&ast.FuncType{
 		Params: &ast.FieldList{
 			List: b.methodConfig.MethodParams,
@@ -146,12 +149,13 @@ func (b *monitoringMethodBuilder) Build() ast.Decl {
 	b.method.AddStatement(increaseTotalOps.Build())
 
 	// Add statement to capture current time
-	//   start := time.Now()
-	b.method.AddStatement(commonbuilders.RecordStartTime(b.timePackageAlias).Build())
+	//   _start := time.Now()
+	startVarName := b.scope.Name(commonbuilders.StartVarName)
+	b.method.AddStatement(commonbuilders.RecordStartTime(b.timePackageAlias, startVarName).Build())
 
 	// Add method invocation:
 	//   result1, result2 := m.next.Method(arg1, arg2)
-	methodInvocation := commonbuilders.NewMethodInvocation(b.methodConfig)
+	methodInvocation := astgen.NewMethodInvocation(b.methodConfig)
 	methodInvocation.SetReceiver(&ast.SelectorExpr{
 		X:   ast.NewIdent("m"), // receiver name
 		Sel: ast.NewIdent("next"),
@@ -159,17 +163,17 @@ func (b *monitoringMethodBuilder) Build() ast.Decl {
 	b.method.AddStatement(methodInvocation.Build())
 
 	// Record operation duration
-	//   m.opsDuration.Observe(time.Since(start))
-	b.method.AddStatement(NewRecordOpDuraton(b.timePackageAlias, b.opsDuration, b.methodConfig.MethodName).Build())
+	//   m.opsDuration.Observe(time.Since(_start))
+	b.method.AddStatement(NewRecordOpDuraton(b.timePackageAlias, startVarName, b.opsDuration, b.methodConfig.MethodName).Build())
 
 	// Add increase failed operations statement
-	//   if err != nil { m.failedOps.Add(1) }
+	//   if [failure condition] { m.failedOps.Add(1) }
 	increaseFailedOps := NewIncreaseFailedOps(b.methodConfig, b.failedOps)
 	b.method.AddStatement(increaseFailedOps.Build())
 
 	// Add return statement
 	//   return result1, result2
-	returnResults := commonbuilders.NewReturnResults(b.methodConfig)
+	returnResults := astgen.NewReturnResults(b.methodConfig)
 	b.method.AddStatement(returnResults.Build())
 
 	return b.method.Build()
@@ -180,6 +184,13 @@ type CounterAddAction struct {
 	operationName string
 }
 
+func NewCounterAddAction(counterField *ast.SelectorExpr, operationName string) *CounterAddAction {
+	return &CounterAddAction{
+		counterField:  counterField,
+		operationName: operationName,
+	}
+}
+
 func (c *CounterAddAction) Build() ast.Stmt {
 	callWithExpr := &ast.CallExpr{
 		Fun: &ast.SelectorExpr{
@@ -217,17 +228,7 @@ func NewIncreaseFailedOps(m *astgen.MethodConfig, counterField *ast.SelectorExpr
 }
 
 func (i *IncreaseFailedOps) Build() ast.Stmt {
-	var errorResult ast.Expr
-	for _, result := range i.method.MethodResults {
-		if id, ok := result.Type.(*ast.Ident); ok {
-			if id.Name == "error" {
-				errorResult = ast.NewIdent(result.Names[0].String())
-				break
-			}
-		}
-	}
-
-	if errorResult == nil {
+	if i.method.FailureCondition == nil {
 		return &ast.EmptyStmt{}
 	}
 
@@ -257,11 +258,7 @@ func (i *IncreaseFailedOps) Build() ast.Stmt {
 	}
 
 	return &ast.IfStmt{
-		Cond: &ast.BinaryExpr{
-			X:  errorResult,
-			Op: token.NEQ,
-			Y:  ast.NewIdent("nil"),
-		},
+		Cond: i.method.FailureCondition,
 		Body: &ast.BlockStmt{
 			List: []ast.Stmt{callStmt},
 		},
@@ -270,13 +267,15 @@ func (i *IncreaseFailedOps) Build() ast.Stmt {
 
 type RecordOpDuration struct {
 	timePackageAlias string
+	startVarName     string
 	opsDuration      *ast.SelectorExpr
 	operationName    string
 }
 
-func NewRecordOpDuraton(timePackageAlias string, opsDuration *ast.SelectorExpr, operationName string) *RecordOpDuration {
+func NewRecordOpDuraton(timePackageAlias, startVarName string, opsDuration *ast.SelectorExpr, operationName string) *RecordOpDuration {
 	return &RecordOpDuration{
 		timePackageAlias: timePackageAlias,
+		startVarName:     startVarName,
 		opsDuration:      opsDuration,
 		operationName:    operationName,
 	}
@@ -288,7 +287,7 @@ func (r RecordOpDuration) Build() ast.Stmt {
 			X:   ast.NewIdent(r.timePackageAlias),
 			Sel: ast.NewIdent("Since"),
 		},
-		Args: []ast.Expr{ast.NewIdent("_start")},
+		Args: []ast.Expr{ast.NewIdent(r.startVarName)},
 	}
 
 	durationSecondsExpr := &ast.CallExpr{
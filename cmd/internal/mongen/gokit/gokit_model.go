@@ -3,7 +3,7 @@ package gokit
 import (
 	"go/ast"
 
-	"github.com/Bo0mer/gentools/cmd/mongen/internal/commonbuilders"
+	"github.com/Bo0mer/gentools/cmd/internal/mongen/commonbuilders"
 	"github.com/Bo0mer/gentools/pkg/astgen"
 )
 
@@ -27,6 +27,8 @@ func NewGoKitModel(interfacePath, interfaceName, structName, targetPkg string) *
 	metricsAlias := m.AddImport("", "github.com/go-kit/kit/metrics")
 	m.timePackageAlias = m.AddImport("", "time")
 
+	file.AppendDeclaration(astgen.NewInterfaceAssertion(sourcePackageAlias, interfaceName, structName))
+
 	constructorBuilder := newConstructorBuilder(metricsAlias, sourcePackageAlias, interfaceName)
 	file.AppendDeclaration(constructorBuilder)
 
@@ -43,7 +45,7 @@ func (m *goKitModel) AddImport(pkgName, location string) string {
 }
 
 func (m *goKitModel) AddMethod(method *astgen.MethodConfig) error {
-	mmb := newMonitoringMethodBuilder(m.structName, method)
+	mmb := newMonitoringMethodBuilder(m.structName, method, m.fileBuilder.MethodScope(method, "m"))
 
 	mmb.SetTimePackageAlias(m.timePackageAlias)
 
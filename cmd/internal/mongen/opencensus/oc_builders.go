@@ -5,7 +5,7 @@ import (
 	"go/ast"
 	"go/token"
 
-	"github.com/Bo0mer/gentools/cmd/mongen/internal/commonbuilders"
+	"github.com/Bo0mer/gentools/cmd/internal/mongen/commonbuilders"
 	"github.com/Bo0mer/gentools/pkg/astgen"
 	"github.com/Bo0mer/gentools/pkg/transformation"
 )
@@ -122,9 +122,10 @@ type ocMonitoringMethodBuilder struct {
 	ctxFuncSel  *ast.SelectorExpr
 
 	packageAliases packageAliases
+	scope          *astgen.Scope
 }
 
-func newOCMonitoringMethodBuilder(structName string, methodConfig *astgen.MethodConfig, aliases packageAliases) *ocMonitoringMethodBuilder {
+func newOCMonitoringMethodBuilder(structName string, methodConfig *astgen.MethodConfig, aliases packageAliases, scope *astgen.Scope) *ocMonitoringMethodBuilder {
 	receiverName := "m"
 	method := astgen.NewMethod(methodConfig.MethodName, receiverName, structName)
 
@@ -144,12 +145,14 @@ func newOCMonitoringMethodBuilder(structName string, methodConfig *astgen.Method
 		opsDuration:    selexpr(commonbuilders.OpsDurationMetricName),
 		ctxFuncSel:     selexpr(commonbuilders.ContextDecoratorFuncName),
 		packageAliases: aliases,
+		scope:          scope,
 	}
 }
 
 func (b *ocMonitoringMethodBuilder) Build() ast.Decl {
 	// Add the func declaration
 	//   func ([b.method.receiverName] [b.method.receiverType]) [funcName]([MethodParams...]) ([MethodResults...]) {
+	b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf("%s calls the wrapped method and records its metrics.", b.methodConfig.MethodName)))
 	b.method.SetType(&ast.FuncType{
 		Params: &ast.FieldList{
 			List: b.methodConfig.MethodParams,
@@ -159,10 +162,11 @@ func (b *ocMonitoringMethodBuilder) Build() ast.Decl {
 		},
 	})
 
-	const (
-		startFieldName = "start"
-		tagKeyVarName  = "tagKey"
-		ctxFieldName   = "ctx"
+	var (
+		ctxFieldName   = b.scope.Name("ctx")
+		tagKeyVarName  = b.scope.Name("tagKey")
+		errVarName     = b.scope.Name("err")
+		startFieldName = b.scope.Name("start")
 	)
 
 	// Add ctx initialization. Can be either
@@ -207,6 +211,7 @@ func (b *ocMonitoringMethodBuilder) Build() ast.Decl {
 		ctxFieldName:      ctxFieldName,
 		tagPackageAlias:   b.packageAliases.tagPkg,
 		tagKeyVarName:     tagKeyVarName,
+		errVarName:        errVarName,
 		wrappedMethodName: snakeCaseMethodName,
 	}
 	b.method.AddStatements(insertInContext.Build())
@@ -229,7 +234,7 @@ func (b *ocMonitoringMethodBuilder) Build() ast.Decl {
 
 	// Add method invocation:
 	//   result1, result2 := m.next.Method(arg1, arg2)
-	methodInvocation := commonbuilders.NewMethodInvocation(b.methodConfig)
+	methodInvocation := astgen.NewMethodInvocation(b.methodConfig)
 	methodInvocation.SetReceiver(&ast.SelectorExpr{
 		X:   ast.NewIdent(b.receiverName),
 		Sel: ast.NewIdent("next"),
@@ -247,7 +252,7 @@ func (b *ocMonitoringMethodBuilder) Build() ast.Decl {
 	}.Build())
 
 	// Add increase failed operations statement
-	//   if err != nil { m.failedOps.Add(1) }
+	//   if [failure condition] { stats.Record(ctx, m.failedOps.M(1)) }
 	b.method.AddStatement(incrementFailedOps{
 		failedOpsField:    b.failedOps,
 		method:            b.methodConfig,
@@ -258,7 +263,7 @@ func (b *ocMonitoringMethodBuilder) Build() ast.Decl {
 
 	// Add return statement
 	//   return result1, result2
-	returnResults := commonbuilders.NewReturnResults(b.methodConfig)
+	returnResults := astgen.NewReturnResults(b.methodConfig)
 	b.method.AddStatement(returnResults.Build())
 
 	return b.method.Build()
@@ -287,16 +292,8 @@ func (c contextParam) Build() ast.Stmt {
 		},
 	}
 
-	if len(c.methodConfig.MethodParams) > 0 {
-		p1 := c.methodConfig.MethodParams[0]
-		if sel, ok := p1.Type.(*ast.SelectorExpr); ok {
-			if sel.Sel.String() == "Context" {
-				if id, ok := sel.X.(*ast.Ident); ok && id.String() == c.ctxPackageAlias {
-					// names is already populated by
-					rhs = []ast.Expr{p1.Names[0]}
-				}
-			}
-		}
+	if ctxArgName, ok := c.methodConfig.ContextParamName(c.ctxPackageAlias); ok {
+		rhs = []ast.Expr{ast.NewIdent(ctxArgName)}
 	}
 
 	return &ast.AssignStmt{
@@ -379,6 +376,7 @@ type insertTagInContext struct {
 	ctxFieldName      string
 	tagPackageAlias   string
 	tagKeyVarName     string
+	errVarName        string
 	wrappedMethodName string
 }
 
@@ -391,7 +389,7 @@ type insertTagInContext struct {
 func (t insertTagInContext) Build() []ast.Stmt {
 	var stmts []ast.Stmt
 
-	errSel := ast.NewIdent("err")
+	errSel := ast.NewIdent(t.errVarName)
 	newTagStmt := t.buildNewTagStmt(errSel)
 
 	stmts = append(stmts, t.buildVarErrStmt(errSel))
@@ -547,28 +545,16 @@ type incrementFailedOps struct {
 	statsPackageAlias string
 }
 
+// Build builds a statement in the form:
+//   if [failure condition] { stats.Record(ctx, [failedOpsField].M(1)) }
 func (i incrementFailedOps) Build() ast.Stmt {
-	var errorResult ast.Expr
-	for _, result := range i.method.MethodResults {
-		if id, ok := result.Type.(*ast.Ident); ok {
-			if id.Name == "error" {
-				errorResult = ast.NewIdent(result.Names[0].String())
-				break
-			}
-		}
-	}
-
-	// none of the returns is an error type
-	if errorResult == nil {
+	// the method cannot fail
+	if i.method.FailureCondition == nil {
 		return &ast.EmptyStmt{}
 	}
 
 	return &ast.IfStmt{
-		Cond: &ast.BinaryExpr{
-			X:  errorResult,
-			Op: token.NEQ,
-			Y:  ast.NewIdent("nil"),
-		},
+		Cond: i.method.FailureCondition,
 		Body: &ast.BlockStmt{
 			List: []ast.Stmt{recordStat{
 				statsPackageAlias: i.statsPackageAlias,
@@ -3,7 +3,7 @@ package opencensus
 import (
 	"go/ast"
 
-	"github.com/Bo0mer/gentools/cmd/mongen/internal/commonbuilders"
+	"github.com/Bo0mer/gentools/cmd/internal/mongen/commonbuilders"
 	"github.com/Bo0mer/gentools/pkg/astgen"
 )
 
@@ -46,6 +46,8 @@ func NewOpencensusModel(interfacePath, interfaceName, structName, targetPkg stri
 	strct.AddFieldWithType(commonbuilders.ContextDecoratorFuncName, buildCtxFuncType(m.packageAliases.contextPkg))
 	file.AppendDeclaration(strct)
 
+	file.AppendDeclaration(astgen.NewInterfaceAssertion(sourcePackageAlias, interfaceName, structName))
+
 	constructorBuilder := newOCConstructorBuilder(
 		m.packageAliases.statsPkg, m.packageAliases.contextPkg, sourcePackageAlias, interfaceName)
 	file.AppendDeclaration(constructorBuilder)
@@ -58,7 +60,7 @@ func (m *opencensusModel) AddImport(pkgName, location string) string {
 }
 
 func (m *opencensusModel) AddMethod(method *astgen.MethodConfig) error {
-	mmb := newOCMonitoringMethodBuilder(m.structName, method, m.packageAliases)
+	mmb := newOCMonitoringMethodBuilder(m.structName, method, m.packageAliases, m.fileBuilder.MethodScope(method, "m"))
 
 	m.fileBuilder.AppendDeclaration(mmb)
 	return nil
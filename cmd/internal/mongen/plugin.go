@@ -0,0 +1,73 @@
+package mongen
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/Bo0mer/gentools/cmd/internal/mongen/combined"
+	"github.com/Bo0mer/gentools/cmd/internal/mongen/gokit"
+	"github.com/Bo0mer/gentools/cmd/internal/mongen/opencensus"
+	"github.com/Bo0mer/gentools/pkg/plugin"
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+const (
+	goKitProvider      = "go-kit"
+	opencensusProvider = "opencensus"
+)
+
+var combinedFlag bool
+
+// Plugin generates monitoring implementations of interfaces.
+var Plugin = plugin.Plugin{
+	Name:        "mongen",
+	Description: "monitoring",
+	Args:        "[PROVIDER]",
+	ArgsHelp: []string{
+		"PROVIDER         Monitoring provider to be used for the generated code",
+		fmt.Sprintf("                 Can be one of:  %s  %s", goKitProvider, opencensusProvider),
+	},
+	Flags: func(fs *flag.FlagSet) {
+		fs.BoolVar(&combinedFlag, "combined", false, fmt.Sprintf("Also trace calls and log their errors (%s only)", goKitProvider))
+	},
+	FileName: func(cfg plugin.Config) string {
+		return fmt.Sprintf("%s_%s.go", kind(), transformation.ToSnakeCase(cfg.InterfaceName))
+	},
+	NewModel: newModel,
+}
+
+func init() {
+	plugin.Register(Plugin)
+}
+
+func kind() string {
+	if combinedFlag {
+		return "observing"
+	}
+	return "monitoring"
+}
+
+func newModel(cfg plugin.Config) (plugin.Model, error) {
+	if len(cfg.Args) > 1 {
+		return nil, fmt.Errorf("too many arguments provided")
+	}
+	provider := goKitProvider
+	if len(cfg.Args) == 1 {
+		provider = cfg.Args[0]
+	}
+	structName := fmt.Sprintf("%s%s", kind(), cfg.InterfaceName)
+
+	switch provider {
+	case goKitProvider:
+		if combinedFlag {
+			return combined.NewCombinedModel(cfg.InterfacePath, cfg.InterfaceName, structName, cfg.TargetPackage), nil
+		}
+		return gokit.NewGoKitModel(cfg.InterfacePath, cfg.InterfaceName, structName, cfg.TargetPackage), nil
+	case opencensusProvider:
+		if combinedFlag {
+			return nil, fmt.Errorf("combined wrapper requires the %s provider", goKitProvider)
+		}
+		return opencensus.NewOpencensusModel(cfg.InterfacePath, cfg.InterfaceName, structName, cfg.TargetPackage), nil
+	}
+	return nil, fmt.Errorf("unknown monitoring provider: %s", provider)
+}
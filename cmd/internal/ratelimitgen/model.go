@@ -0,0 +1,373 @@
+package ratelimitgen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/Bo0mer/gentools/pkg/astgen"
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+const ratelimitPackagePath = "github.com/Bo0mer/gentools/pkg/middleware/ratelimit"
+
+type model struct {
+	fileBuilder *astgen.File
+	structName  string
+	strct       *astgen.Struct
+	constructor *constructorBuilder
+
+	ratelimitPackageAlias string
+	contextPackageAlias   string
+}
+
+func newModel(interfacePath, interfaceName, structName, targetPkg string) *model {
+	file := astgen.NewFile(targetPkg)
+	strct := astgen.NewStruct(structName)
+	file.AppendDeclaration(strct)
+
+	m := &model{
+		fileBuilder: file,
+		structName:  structName,
+		strct:       strct,
+	}
+	sourcePackageAlias := m.AddImport("", interfacePath)
+	m.ratelimitPackageAlias = m.AddImport("", ratelimitPackagePath)
+
+	file.AppendDeclaration(astgen.NewInterfaceAssertion(sourcePackageAlias, interfaceName, structName))
+
+	m.constructor = newConstructorBuilder(m.ratelimitPackageAlias, sourcePackageAlias, interfaceName, structName)
+	file.AppendDeclaration(m.constructor)
+
+	strct.AddField("next", sourcePackageAlias, interfaceName)
+
+	return m
+}
+
+func (m *model) Build() *ast.File {
+	return m.fileBuilder.Build()
+}
+
+func (m *model) AddImport(pkgName, location string) string {
+	if location == "context" {
+		m.contextPackageAlias = m.fileBuilder.AddImport(pkgName, location)
+		return m.contextPackageAlias
+	}
+	return m.fileBuilder.AddImport(pkgName, location)
+}
+
+func (m *model) AddMethod(method *astgen.MethodConfig) error {
+	// Only calls that can report the rejection through an error result are
+	// limited.
+	limitedResult, ok := method.ErrorResultName()
+	if !ok {
+		m.fileBuilder.AppendDeclaration(newRateLimitedMethodBuilder(m.structName, method, m.ratelimitPackageAlias, "", ""))
+		return nil
+	}
+
+	limiterField := transformation.ToUnexported(method.MethodName) + "Limiter"
+	m.strct.AddField(limiterField, m.ratelimitPackageAlias, "Limiter")
+	m.constructor.addLimitedMethod(method.MethodName, limiterField)
+
+	mmb := newRateLimitedMethodBuilder(m.structName, method, m.ratelimitPackageAlias, limiterField, limitedResult)
+	if ctxArgName, ok := method.ContextParamName(m.contextPackageAlias); ok {
+		mmb.setContextArgName(ctxArgName)
+	}
+	m.fileBuilder.AppendDeclaration(mmb)
+	return nil
+}
+
+type constructorBuilder struct {
+	ratelimitPackageName string
+	interfacePackageName string
+	interfaceName        string
+	structName           string
+
+	methodNames   []string
+	limiterFields []string
+}
+
+func newConstructorBuilder(ratelimitPackageName, packageName, interfaceName, structName string) *constructorBuilder {
+	return &constructorBuilder{
+		ratelimitPackageName: ratelimitPackageName,
+		interfacePackageName: packageName,
+		interfaceName:        interfaceName,
+		structName:           structName,
+	}
+}
+
+func (c *constructorBuilder) addLimitedMethod(methodName, limiterField string) {
+	c.methodNames = append(c.methodNames, methodName)
+	c.limiterFields = append(c.limiterFields, limiterField)
+}
+
+// Build builds the constructor, which obtains the limiters of all limited
+// methods upfront:
+//   limiters := ratelimit.NewLimiters(limiter, opts...)
+//   return &rateLimitedService{
+//     next:          next,
+//     doWorkLimiter: limiters.Limiter("DoWork"),
+//   }
+func (c *constructorBuilder) Build() ast.Decl {
+	elts := []ast.Expr{
+		&ast.KeyValueExpr{
+			Key:   ast.NewIdent("next"),
+			Value: ast.NewIdent("next"),
+		},
+	}
+	for i, methodName := range c.methodNames {
+		elts = append(elts, &ast.KeyValueExpr{
+			Key: ast.NewIdent(c.limiterFields[i]),
+			Value: &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   ast.NewIdent("limiters"),
+					Sel: ast.NewIdent("Limiter"),
+				},
+				Args: []ast.Expr{
+					&ast.BasicLit{Kind: token.STRING, Value: fmt.Sprintf("%q", methodName)},
+				},
+			},
+		})
+	}
+
+	var stmts []ast.Stmt
+	if len(c.methodNames) > 0 {
+		stmts = append(stmts, &ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent("limiters")},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   ast.NewIdent(c.ratelimitPackageName),
+						Sel: ast.NewIdent("NewLimiters"),
+					},
+					Args:     []ast.Expr{ast.NewIdent("limiter"), ast.NewIdent("opts")},
+					Ellipsis: 1,
+				},
+			},
+		})
+	}
+	stmts = append(stmts, &ast.ReturnStmt{
+		Results: []ast.Expr{
+			&ast.UnaryExpr{
+				Op: token.AND,
+				X: &ast.CompositeLit{
+					Type: ast.NewIdent(c.structName),
+					Elts: elts,
+				},
+			},
+		},
+	})
+
+	funcName := fmt.Sprintf("NewRateLimited%s", c.interfaceName)
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{
+			List: []*ast.Comment{
+				{
+					Text: fmt.Sprintf("// %s creates new rate limiting middleware.", funcName),
+				},
+			},
+		},
+		Name: ast.NewIdent(funcName),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("next")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interfacePackageName),
+							Sel: ast.NewIdent(c.interfaceName),
+						},
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("limiter")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.ratelimitPackageName),
+							Sel: ast.NewIdent("Limiter"),
+						},
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("opts")},
+						Type: &ast.Ellipsis{
+							Elt: &ast.SelectorExpr{
+								X:   ast.NewIdent(c.ratelimitPackageName),
+								Sel: ast.NewIdent("Option"),
+							},
+						},
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interfacePackageName),
+							Sel: ast.NewIdent(c.interfaceName),
+						},
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{List: stmts},
+	}
+}
+
+// rateLimitedMethodBuilder is responsible for creating a method that
+// implements the original method from the interface and limits the rate of
+// the calls to it.
+type rateLimitedMethodBuilder struct {
+	methodConfig          *astgen.MethodConfig
+	method                *astgen.Method
+	ratelimitPackageAlias string
+	limiterField          string
+	limitedResult         string
+	ctxArgName            string
+}
+
+func newRateLimitedMethodBuilder(structName string, methodConfig *astgen.MethodConfig, ratelimitPackageAlias, limiterField, limitedResult string) *rateLimitedMethodBuilder {
+	method := astgen.NewMethod(methodConfig.MethodName, "m", structName)
+
+	return &rateLimitedMethodBuilder{
+		methodConfig:          methodConfig,
+		method:                method,
+		ratelimitPackageAlias: ratelimitPackageAlias,
+		limiterField:          limiterField,
+		limitedResult:         limitedResult,
+	}
+}
+
+// setContextArgName makes the method wait for the limiter, for as long as
+// the context argument with the specified name allows, instead of failing
+// fast.
+func (b *rateLimitedMethodBuilder) setContextArgName(name string) {
+	b.ctxArgName = name
+}
+
+func (b *rateLimitedMethodBuilder) Build() ast.Decl {
+	b.method.SetType(&ast.FuncType{
+		Params: &ast.FieldList{
+			List: b.methodConfig.MethodParams,
+		},
+		Results: &ast.FieldList{
+			List: transformation.FieldsAsAnonymous(b.methodConfig.MethodResults),
+		},
+	})
+
+	methodInvocation := astgen.NewMethodInvocation(b.methodConfig)
+	methodInvocation.SetReceiver(&ast.SelectorExpr{
+		X:   ast.NewIdent("m"), // receiver name
+		Sel: ast.NewIdent("next"),
+	})
+
+	summary := "%s calls the wrapped method."
+	if b.limiterField != "" {
+		summary = "%s calls the wrapped method, unless its rate limit is exceeded."
+		if b.ctxArgName != "" {
+			summary = "%s calls the wrapped method, once its rate limit allows it."
+		}
+
+		// Declare the results, so that rejected calls return zero values:
+		//   var result1 string
+		//   var result2 error
+		b.method.AddStatements(astgen.NewDeclareResults(b.methodConfig).Build())
+
+		if b.ctxArgName != "" {
+			b.method.AddStatement(b.waitStmt())
+		} else {
+			b.method.AddStatement(b.allowStmt())
+		}
+	}
+
+	// Add method invocation:
+	//   return m.next.Method(arg1, arg2)
+	b.method.AddStatement(methodInvocation.BuildReturn())
+
+	b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf(summary, b.methodConfig.MethodName)))
+	return b.method.Build()
+}
+
+// waitStmt builds the statement which waits for the limiter:
+//   if result2 = m.doWorkLimiter.Wait(ctx); result2 != nil {
+//     return result1, result2
+//   }
+func (b *rateLimitedMethodBuilder) waitStmt() ast.Stmt {
+	return &ast.IfStmt{
+		Init: &ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(b.limitedResult)},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   b.limiterSelector(),
+						Sel: ast.NewIdent("Wait"),
+					},
+					Args: []ast.Expr{ast.NewIdent(b.ctxArgName)},
+				},
+			},
+		},
+		Cond: &ast.BinaryExpr{
+			X:  ast.NewIdent(b.limitedResult),
+			Op: token.NEQ,
+			Y:  ast.NewIdent("nil"),
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{astgen.NewReturnResults(b.methodConfig).Build()},
+		},
+	}
+}
+
+// allowStmt builds the statement which rejects the call if the limiter does
+// not allow it right away:
+//   if !m.doWorkLimiter.Allow() {
+//     result2 = &ratelimit.LimitExceededError{Method: "DoWork"}
+//     return result1, result2
+//   }
+func (b *rateLimitedMethodBuilder) allowStmt() ast.Stmt {
+	return &ast.IfStmt{
+		Cond: &ast.UnaryExpr{
+			Op: token.NOT,
+			X: &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   b.limiterSelector(),
+					Sel: ast.NewIdent("Allow"),
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{ast.NewIdent(b.limitedResult)},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{
+						&ast.UnaryExpr{
+							Op: token.AND,
+							X: &ast.CompositeLit{
+								Type: &ast.SelectorExpr{
+									X:   ast.NewIdent(b.ratelimitPackageAlias),
+									Sel: ast.NewIdent("LimitExceededError"),
+								},
+								Elts: []ast.Expr{
+									&ast.KeyValueExpr{
+										Key: ast.NewIdent("Method"),
+										Value: &ast.BasicLit{
+											Kind:  token.STRING,
+											Value: fmt.Sprintf("%q", b.methodConfig.MethodName),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				astgen.NewReturnResults(b.methodConfig).Build(),
+			},
+		},
+	}
+}
+
+func (b *rateLimitedMethodBuilder) limiterSelector() ast.Expr {
+	return &ast.SelectorExpr{
+		X:   ast.NewIdent("m"), // receiver name
+		Sel: ast.NewIdent(b.limiterField),
+	}
+}
@@ -0,0 +1,25 @@
+package ratelimitgen
+
+import (
+	"fmt"
+
+	"github.com/Bo0mer/gentools/pkg/plugin"
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+// Plugin generates rate limiting implementations of interfaces.
+var Plugin = plugin.Plugin{
+	Name:        "ratelimitgen",
+	Description: "rate limiting",
+	FileName: func(cfg plugin.Config) string {
+		return fmt.Sprintf("rate_limited_%s.go", transformation.ToSnakeCase(cfg.InterfaceName))
+	},
+	NewModel: func(cfg plugin.Config) (plugin.Model, error) {
+		typeName := fmt.Sprintf("rateLimited%s", cfg.InterfaceName)
+		return newModel(cfg.InterfacePath, cfg.InterfaceName, typeName, cfg.TargetPackage), nil
+	},
+}
+
+func init() {
+	plugin.Register(Plugin)
+}
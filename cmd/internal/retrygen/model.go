@@ -0,0 +1,364 @@
+package retrygen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/Bo0mer/gentools/pkg/astgen"
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+const (
+	// noRetryAnnotation opts a method out of retries, e.g. because it is not
+	// idempotent:
+	//   //gentools:noretry
+	noRetryAnnotation = "noretry"
+
+	retryPackagePath = "github.com/Bo0mer/gentools/pkg/middleware/retry"
+)
+
+type model struct {
+	fileBuilder *astgen.File
+	structName  string
+
+	retryPackageAlias   string
+	contextPackageAlias string
+}
+
+func newModel(interfacePath, interfaceName, structName, targetPkg string) *model {
+	file := astgen.NewFile(targetPkg)
+	strct := astgen.NewStruct(structName)
+	file.AppendDeclaration(strct)
+
+	m := &model{
+		fileBuilder: file,
+		structName:  structName,
+	}
+	sourcePackageAlias := m.AddImport("", interfacePath)
+	m.retryPackageAlias = m.AddImport("", retryPackagePath)
+
+	file.AppendDeclaration(astgen.NewInterfaceAssertion(sourcePackageAlias, interfaceName, structName))
+
+	constructorBuilder := newConstructorBuilder(m.retryPackageAlias, sourcePackageAlias, interfaceName)
+	file.AppendDeclaration(constructorBuilder)
+
+	strct.AddField("next", sourcePackageAlias, interfaceName)
+	strct.AddField("policy", m.retryPackageAlias, "Policy")
+
+	return m
+}
+
+func (m *model) Build() *ast.File {
+	return m.fileBuilder.Build()
+}
+
+func (m *model) AddImport(pkgName, location string) string {
+	if location == "context" {
+		m.contextPackageAlias = m.fileBuilder.AddImport(pkgName, location)
+		return m.contextPackageAlias
+	}
+	return m.fileBuilder.AddImport(pkgName, location)
+}
+
+func (m *model) AddMethod(method *astgen.MethodConfig) error {
+	retried := method.FailureCondition != nil && !method.Annotations.Has(noRetryAnnotation)
+
+	var ctxExpr ast.Expr
+	if retried {
+		if ctxArgName, ok := method.ContextParamName(m.contextPackageAlias); ok {
+			ctxExpr = ast.NewIdent(ctxArgName)
+		} else {
+			// context.Background()
+			ctxExpr = &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   ast.NewIdent(m.AddImport("", "context")),
+					Sel: ast.NewIdent("Background"),
+				},
+			}
+		}
+	}
+
+	mmb := newRetryingMethodBuilder(m.structName, method, m.retryPackageAlias, ctxExpr, retried, m.fileBuilder.MethodScope(method, "m"))
+	m.fileBuilder.AppendDeclaration(mmb)
+	return nil
+}
+
+type constructorBuilder struct {
+	retryPackageName     string
+	interfacePackageName string
+	interfaceName        string
+}
+
+func newConstructorBuilder(retryPackageName, packageName, interfaceName string) *constructorBuilder {
+	return &constructorBuilder{
+		retryPackageName:     retryPackageName,
+		interfacePackageName: packageName,
+		interfaceName:        interfaceName,
+	}
+}
+
+func (c *constructorBuilder) Build() ast.Decl {
+	funcBody := &ast.BlockStmt{
+		List: []ast.Stmt{
+			&ast.ReturnStmt{
+				Results: []ast.Expr{
+					&ast.UnaryExpr{
+						Op: token.AND,
+						X: &ast.CompositeLit{
+							Type: ast.NewIdent(fmt.Sprintf("retrying%s", c.interfaceName)),
+							Elts: []ast.Expr{
+								ast.NewIdent("next"),
+								ast.NewIdent("policy"),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	funcName := fmt.Sprintf("NewRetrying%s", c.interfaceName)
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{
+			List: []*ast.Comment{
+				{
+					Text: fmt.Sprintf("// %s creates new retrying middleware.", funcName),
+				},
+			},
+		},
+		Name: ast.NewIdent(funcName),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("next")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interfacePackageName),
+							Sel: ast.NewIdent(c.interfaceName),
+						},
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("policy")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.retryPackageName),
+							Sel: ast.NewIdent("Policy"),
+						},
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interfacePackageName),
+							Sel: ast.NewIdent(c.interfaceName),
+						},
+					},
+				},
+			},
+		},
+		Body: funcBody,
+	}
+}
+
+// retryingMethodBuilder is responsible for creating a method that implements
+// the original method from the interface and retries the call according to
+// the policy of the middleware.
+type retryingMethodBuilder struct {
+	methodConfig      *astgen.MethodConfig
+	method            *astgen.Method
+	retryPackageAlias string
+	ctxExpr           ast.Expr
+	retried           bool
+	scope             *astgen.Scope
+}
+
+func newRetryingMethodBuilder(structName string, methodConfig *astgen.MethodConfig, retryPackageAlias string, ctxExpr ast.Expr, retried bool, scope *astgen.Scope) *retryingMethodBuilder {
+	method := astgen.NewMethod(methodConfig.MethodName, "m", structName)
+
+	return &retryingMethodBuilder{
+		methodConfig:      methodConfig,
+		method:            method,
+		retryPackageAlias: retryPackageAlias,
+		ctxExpr:           ctxExpr,
+		retried:           retried,
+		scope:             scope,
+	}
+}
+
+func (b *retryingMethodBuilder) Build() ast.Decl {
+	b.method.SetType(&ast.FuncType{
+		Params: &ast.FieldList{
+			List: b.methodConfig.MethodParams,
+		},
+		Results: &ast.FieldList{
+			List: transformation.FieldsAsAnonymous(b.methodConfig.MethodResults),
+		},
+	})
+
+	methodInvocation := astgen.NewMethodInvocation(b.methodConfig)
+	methodInvocation.SetReceiver(&ast.SelectorExpr{
+		X:   ast.NewIdent("m"), // receiver name
+		Sel: ast.NewIdent("next"),
+	})
+
+	// Methods that cannot fail or opted out of retries are proxied:
+	//   return m.next.Method(arg1, arg2)
+	if !b.retried {
+		b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf("%s calls the wrapped method.", b.methodConfig.MethodName)))
+		b.method.AddStatement(methodInvocation.BuildReturn())
+		return b.method.Build()
+	}
+	b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf("%s calls the wrapped method, retrying its failures according to the policy.", b.methodConfig.MethodName)))
+
+	// Declare the results, so that they outlive the attempts:
+	//   var result1 string
+	//   var result2 error
+	b.method.AddStatements(astgen.NewDeclareResults(b.methodConfig).Build())
+
+	// Add the retried invocation:
+	//   m.policy.Do(ctx, func() error {
+	//     result1, result2 = m.next.Method(arg1, arg2)
+	//     if result2 != nil {
+	//       return result2
+	//     }
+	//     return nil
+	//   })
+	attempt := &ast.FuncLit{
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{},
+			Results: &ast.FieldList{
+				List: []*ast.Field{{Type: ast.NewIdent("error")}},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: append([]ast.Stmt{methodInvocation.BuildAssign()}, b.reportFailureStmts()...),
+		},
+	}
+	do := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X: &ast.SelectorExpr{
+				X:   ast.NewIdent("m"), // receiver name
+				Sel: ast.NewIdent("policy"),
+			},
+			Sel: ast.NewIdent("Do"),
+		},
+		Args: []ast.Expr{b.ctxExpr, attempt},
+	}
+	ctx, ok := b.ctxExpr.(*ast.Ident)
+	errResult, hasErr := b.contextErrResult()
+	if !ok || !hasErr {
+		// The background context is never done, and the error of the
+		// context cannot be returned by methods without an error result.
+		b.method.AddStatement(&ast.ExprStmt{X: do})
+		b.method.AddStatement(astgen.NewReturnResults(b.methodConfig).Build())
+		return b.method.Build()
+	}
+
+	// Return the error of the context if it was done while waiting between
+	// attempts, rather than the error of the last attempt:
+	//   if err := m.policy.Do(ctx, func() error {
+	//     ...
+	//   }); err != nil && err == ctx.Err() {
+	//     result2 = err
+	//   }
+	err := ast.NewIdent(b.scope.Name("err"))
+	b.method.AddStatement(&ast.IfStmt{
+		Init: &ast.AssignStmt{
+			Lhs: []ast.Expr{err},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{do},
+		},
+		Cond: &ast.BinaryExpr{
+			X: &ast.BinaryExpr{
+				X:  err,
+				Op: token.NEQ,
+				Y:  ast.NewIdent("nil"),
+			},
+			Op: token.LAND,
+			Y: &ast.BinaryExpr{
+				X:  err,
+				Op: token.EQL,
+				Y: &ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   ctx,
+						Sel: ast.NewIdent("Err"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{ast.NewIdent(errResult)},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{err},
+				},
+			},
+		},
+	})
+
+	// Add return statement
+	//   return result1, result2
+	b.method.AddStatement(astgen.NewReturnResults(b.methodConfig).Build())
+
+	return b.method.Build()
+}
+
+// contextErrResult returns the name of the first result of type error, to
+// which the error of the context is assigned, or false if there is none.
+// Results of custom error types cannot hold it.
+func (b *retryingMethodBuilder) contextErrResult() (string, bool) {
+	for _, result := range b.methodConfig.ErrorResults {
+		if id, ok := result.Type.(*ast.Ident); ok && id.Name == "error" {
+			return result.Names[0].String(), true
+		}
+	}
+	return "", false
+}
+
+// reportFailureStmts builds the statements which report the outcome of an
+// attempt to the policy. A failed attempt is reported with its first non-nil
+// error result or, if there is none, with retry.ErrFailed.
+func (b *retryingMethodBuilder) reportFailureStmts() []ast.Stmt {
+	var returnErrorStmts []ast.Stmt
+	for _, result := range b.methodConfig.ErrorResults {
+		name := result.Names[0].String()
+		returnErrorStmts = append(returnErrorStmts, &ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X:  ast.NewIdent(name),
+				Op: token.NEQ,
+				Y:  ast.NewIdent("nil"),
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.ReturnStmt{Results: []ast.Expr{ast.NewIdent(name)}},
+				},
+			},
+		})
+	}
+	returnNil := &ast.ReturnStmt{Results: []ast.Expr{ast.NewIdent("nil")}}
+
+	if !b.methodConfig.HasCustomFailure() {
+		return append(returnErrorStmts, returnNil)
+	}
+
+	returnErrFailed := &ast.ReturnStmt{
+		Results: []ast.Expr{
+			&ast.SelectorExpr{
+				X:   ast.NewIdent(b.retryPackageAlias),
+				Sel: ast.NewIdent("ErrFailed"),
+			},
+		},
+	}
+	return []ast.Stmt{
+		&ast.IfStmt{
+			Cond: b.methodConfig.FailureCondition,
+			Body: &ast.BlockStmt{
+				List: append(returnErrorStmts, returnErrFailed),
+			},
+		},
+		returnNil,
+	}
+}
@@ -0,0 +1,112 @@
+package templgen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+// funcs are the helper funcs available to the templates.
+var funcs = template.FuncMap{
+	"snake":       transformation.ToSnakeCase,
+	"unexported":  transformation.ToUnexported,
+	"quote":       strconv.Quote,
+	"params":      params,
+	"results":     results,
+	"args":        args,
+	"resultNames": resultNames,
+	"call":        call,
+	"signature":   signature,
+	"comment":     comment,
+}
+
+// params returns the parameter list of the method:
+//   arg1 context.Context, arg2 int, arg3 ...string
+func params(m *Method) string {
+	var list []string
+	for _, p := range m.Params {
+		typ := p.Type
+		if p.Variadic {
+			typ = "..." + typ
+		}
+		list = append(list, fmt.Sprintf("%s %s", p.Name, typ))
+	}
+	return strings.Join(list, ", ")
+}
+
+// results returns the result list of the method, parenthesized if needed:
+//   (string, error)
+func results(m *Method) string {
+	var list []string
+	for _, r := range m.Results {
+		list = append(list, r.Type)
+	}
+	if len(list) == 1 {
+		return list[0]
+	}
+	if len(list) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(list, ", ") + ")"
+}
+
+// args returns the arguments which pass the parameters of the method on to
+// another call:
+//   arg1, arg2, arg3...
+func args(m *Method) string {
+	var list []string
+	for _, p := range m.Params {
+		arg := p.Name
+		if p.Variadic {
+			arg += "..."
+		}
+		list = append(list, arg)
+	}
+	return strings.Join(list, ", ")
+}
+
+// resultNames returns the names of the results of the method:
+//   result1, result2
+func resultNames(m *Method) string {
+	var list []string
+	for _, r := range m.Results {
+		list = append(list, r.Name)
+	}
+	return strings.Join(list, ", ")
+}
+
+// call returns an expression which calls the method on the receiver:
+//   m.next.Method(arg1, arg2, arg3...)
+func call(receiver string, m *Method) string {
+	return fmt.Sprintf("%s.%s(%s)", receiver, m.Name, args(m))
+}
+
+// signature returns the signature of the method, as in a method declaration:
+//   Method(arg1 context.Context, arg2 int) (string, error)
+func signature(m *Method) string {
+	return strings.TrimSpace(fmt.Sprintf("%s(%s) %s", m.Name, params(m), results(m)))
+}
+
+// comment returns the paragraphs as the lines of a comment, separated by
+// empty comment lines. Empty paragraphs are skipped:
+//   // Method counts the calls to the wrapped method.
+//   //
+//   // Method does the work.
+func comment(paragraphs ...string) string {
+	var lines []string
+	for _, paragraph := range paragraphs {
+		if paragraph == "" {
+			continue
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "//")
+		}
+		for _, line := range strings.Split(paragraph, "\n") {
+			lines = append(lines, strings.TrimSpace("// "+line))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,246 @@
+package templgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"text/template"
+
+	"github.com/Bo0mer/gentools/pkg/astgen"
+	"github.com/Bo0mer/gentools/pkg/plugin"
+	"golang.org/x/tools/imports"
+)
+
+// File is the data passed to the templates.
+type File struct {
+	// Package is the name of the package of the generated file.
+	Package string
+	// Interface is the interface being implemented.
+	Interface Interface
+	// Imports are all packages referred to by the types of the methods.
+	Imports []Import
+	// Methods are all methods of the interface, including the embedded ones.
+	Methods []*Method
+}
+
+// Interface describes the interface being implemented.
+type Interface struct {
+	// Name is the name of the interface, e.g. Service.
+	Name string
+	// Path is the import path of the package of the interface.
+	Path string
+	// Alias is the alias under which the package of the interface is
+	// imported, e.g. alias1 in alias1.Service. It is empty for structs.
+	Alias string
+	// Type is the implemented interface as referred to from the generated
+	// package, e.g. alias1.Service, or the name of the interface declared
+	// for a struct, e.g. Legacy.
+	Type string
+}
+
+// Import describes an imported package.
+type Import struct {
+	Alias string
+	Path  string
+}
+
+// Method describes a method of the interface. All types are resolved
+// against the imports of the generated file.
+type Method struct {
+	Name    string
+	Params  []*Var
+	Results []*Var
+	// ContextParam is the context.Context first parameter, or nil if the
+	// method does not take one.
+	ContextParam *Var
+	// ErrorResults are the results whose types implement error and can be
+	// nil.
+	ErrorResults []*Var
+	// FailureCondition is the boolean expression, in terms of the results,
+	// which reports whether a call has failed. It is empty for methods that
+	// cannot fail.
+	FailureCondition string
+	// Annotations are the gentools annotations of the method.
+	Annotations astgen.Annotations
+	// Doc is the text of the doc comment of the method, without the
+	// gentools annotations.
+	Doc string
+	// Deprecated is the deprecation notice of the method, e.g.
+	// "Deprecated: Use Find instead.", or empty if it is not deprecated.
+	Deprecated string
+}
+
+// Var describes a parameter or a result of a method.
+type Var struct {
+	Name string
+	Type string
+	// Variadic reports whether the parameter is variadic, in which case Type
+	// is the type of its elements.
+	Variadic bool
+}
+
+type model struct {
+	fileBuilder         *astgen.File
+	file                File
+	contextPackageAlias string
+
+	tmpl     *template.Template
+	fset     *token.FileSet
+	filename string
+	built    *ast.File
+}
+
+func newModel(cfg plugin.Config) (plugin.Model, error) {
+	if len(cfg.Args) == 0 {
+		return nil, errors.New("no templates provided")
+	}
+	tmpl, err := template.New(filepath.Base(cfg.Args[0])).Funcs(funcs).ParseFiles(cfg.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing templates: %v", err)
+	}
+
+	m := &model{
+		fileBuilder: astgen.NewFile(cfg.TargetPackage),
+		tmpl:        tmpl,
+		fset:        cfg.FileSet,
+		filename:    filename(cfg.Args[0], cfg.InterfaceName),
+	}
+	alias := m.AddImport("", cfg.InterfacePath)
+	m.file = File{
+		Package: cfg.TargetPackage,
+		Interface: Interface{
+			Name:  cfg.InterfaceName,
+			Path:  cfg.InterfacePath,
+			Alias: alias,
+			Type:  alias + "." + cfg.InterfaceName,
+		},
+	}
+	m.contextPackageAlias = m.AddImport("", "context")
+
+	return m, nil
+}
+
+func (m *model) AddImport(pkgName, location string) string {
+	return m.fileBuilder.AddImport(pkgName, location)
+}
+
+// LocalizeInterface makes the templates refer to the interface declared for
+// a struct, which is not imported.
+func (m *model) LocalizeInterface(name string) {
+	m.file.Interface.Alias = ""
+	m.file.Interface.Type = name
+}
+
+func (m *model) AddMethod(method *astgen.MethodConfig) error {
+	mm := &Method{
+		Name:        method.MethodName,
+		Annotations: method.Annotations,
+		Doc:         method.Doc,
+		Deprecated:  method.Deprecated,
+	}
+	for _, param := range method.MethodParams {
+		mm.Params = append(mm.Params, newVar(param))
+	}
+	for _, result := range method.MethodResults {
+		mm.Results = append(mm.Results, newVar(result))
+	}
+	for _, result := range method.ErrorResults {
+		mm.ErrorResults = append(mm.ErrorResults, newVar(result))
+	}
+	if _, ok := method.ContextParamName(m.contextPackageAlias); ok {
+		mm.ContextParam = mm.Params[0]
+	}
+	if method.FailureCondition != nil {
+		mm.FailureCondition = exprString(method.FailureCondition)
+	}
+
+	m.file.Methods = append(m.file.Methods, mm)
+	return nil
+}
+
+// Validate executes the template against the collected methods and parses
+// the result, with its imports fixed, so that errors in the template are
+// reported before the file is built.
+func (m *model) Validate() error {
+	m.file.Imports = nil
+	for alias, location := range m.fileBuilder.Imports() {
+		m.file.Imports = append(m.file.Imports, Import{Alias: alias, Path: location})
+	}
+	sort.Slice(m.file.Imports, func(i, j int) bool {
+		return m.file.Imports[i].Path < m.file.Imports[j].Path
+	})
+
+	var buf bytes.Buffer
+	if err := m.tmpl.Execute(&buf, m.file); err != nil {
+		return err
+	}
+	src, err := imports.Process(m.filename, buf.Bytes(), nil)
+	if err != nil {
+		return fmt.Errorf("error formatting generated source: %v", err)
+	}
+	file, err := parser.ParseFile(m.fset, m.filename, src, parser.ParseComments)
+	if err != nil {
+		return fmt.Errorf("error parsing generated source: %v", err)
+	}
+
+	// The package is documented along with the header of the file.
+	comments := []*ast.CommentGroup{}
+	for _, comment := range file.Comments {
+		if comment != file.Doc {
+			comments = append(comments, comment)
+		}
+	}
+	file.Comments, file.Doc = comments, nil
+	nameImports(file)
+	m.built = file
+	return nil
+}
+
+func (m *model) Build() *ast.File {
+	return m.built
+}
+
+// nameImports names the imports added by goimports after their packages, as
+// the imports of the generated files are merged by their names.
+func nameImports(file *ast.File) {
+	for _, imp := range file.Imports {
+		if imp.Name != nil {
+			continue
+		}
+		location, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path.Base(location)
+		if pkg, err := build.Import(location, "", 0); err == nil {
+			name = pkg.Name
+		}
+		imp.Name = &ast.Ident{NamePos: imp.Path.Pos(), Name: name}
+	}
+}
+
+func newVar(field *ast.Field) *Var {
+	v := &Var{Name: field.Names[0].String()}
+	typ := field.Type
+	if ellipsis, ok := typ.(*ast.Ellipsis); ok {
+		v.Variadic = true
+		typ = ellipsis.Elt
+	}
+	v.Type = exprString(typ)
+	return v
+}
+
+func exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, token.NewFileSet(), expr)
+	return buf.String()
+}
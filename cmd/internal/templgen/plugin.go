@@ -0,0 +1,39 @@
+package templgen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Bo0mer/gentools/pkg/plugin"
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+// Plugin generates implementations of interfaces from templates.
+var Plugin = plugin.Plugin{
+	Name:        "templgen",
+	Description: "templated",
+	Args:        "TEMPLATE [TEMPLATE...]",
+	ArgsHelp: []string{
+		"TEMPLATE         Path to a text/template file. The first one is executed",
+		"                 and can refer to the templates defined in the rest",
+	},
+	FileName: func(cfg plugin.Config) string {
+		return filename(cfg.Args[0], cfg.InterfaceName)
+	},
+	NewModel: newModel,
+}
+
+func init() {
+	plugin.Register(Plugin)
+}
+
+// filename returns the name of the output file, named after the template,
+// e.g. counting_service.go for the counting.go.tmpl template.
+func filename(template, interfaceName string) string {
+	name := filepath.Base(template)
+	if i := strings.Index(name, "."); i > 0 {
+		name = name[:i]
+	}
+	return fmt.Sprintf("%s_%s.go", name, transformation.ToSnakeCase(interfaceName))
+}
@@ -0,0 +1,498 @@
+package timeoutgen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/Bo0mer/gentools/pkg/astgen"
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+const timeoutPackagePath = "github.com/Bo0mer/gentools/pkg/middleware/timeout"
+
+// goroutineDoc documents the calls to methods without a context, which are
+// run in a separate goroutine that cannot be stopped.
+const goroutineDoc = `%s runs the call in a separate goroutine, as the method does not accept a
+context. If the call times out, the goroutine keeps running until the call
+returns and its results are discarded, so calls that never return leak the
+goroutine.`
+
+type model struct {
+	fileBuilder *astgen.File
+	structName  string
+	strct       *astgen.Struct
+	constructor *constructorBuilder
+
+	timeoutPackageAlias string
+	contextPackageAlias string
+}
+
+func newModel(interfacePath, interfaceName, structName, targetPkg string) *model {
+	file := astgen.NewFile(targetPkg)
+	strct := astgen.NewStruct(structName)
+	file.AppendDeclaration(strct)
+
+	m := &model{
+		fileBuilder: file,
+		structName:  structName,
+		strct:       strct,
+	}
+	sourcePackageAlias := m.AddImport("", interfacePath)
+	m.timeoutPackageAlias = m.AddImport("", timeoutPackagePath)
+
+	file.AppendDeclaration(astgen.NewInterfaceAssertion(sourcePackageAlias, interfaceName, structName))
+
+	m.constructor = newConstructorBuilder(m.timeoutPackageAlias, m.AddImport("", "time"), sourcePackageAlias, interfaceName, structName)
+	file.AppendDeclaration(m.constructor)
+
+	strct.AddField("next", sourcePackageAlias, interfaceName)
+
+	return m
+}
+
+func (m *model) Build() *ast.File {
+	return m.fileBuilder.Build()
+}
+
+func (m *model) AddImport(pkgName, location string) string {
+	if location == "context" {
+		m.contextPackageAlias = m.fileBuilder.AddImport(pkgName, location)
+		return m.contextPackageAlias
+	}
+	return m.fileBuilder.AddImport(pkgName, location)
+}
+
+func (m *model) AddMethod(method *astgen.MethodConfig) error {
+	mmb := newTimeoutMethodBuilder(m.structName, method, m.timeoutPackageAlias, m.AddImport("", "time"), m.fileBuilder.MethodScope(method, "m"))
+
+	// Calls with a context are cancelled through it, all others can only
+	// be abandoned and report that through an error result.
+	ctxArgName, hasContext := method.ContextParamName(m.contextPackageAlias)
+	timeoutResult, hasError := method.ErrorResultName()
+	switch {
+	case hasContext:
+		mmb.setContextArgName(ctxArgName)
+	case hasError:
+		mmb.setTimeoutResult(timeoutResult)
+	default:
+		m.fileBuilder.AppendDeclaration(mmb)
+		return nil
+	}
+
+	timeoutField := transformation.ToUnexported(method.MethodName) + "Timeout"
+	m.strct.AddField(timeoutField, m.AddImport("", "time"), "Duration")
+	m.constructor.addTimedMethod(method.MethodName, timeoutField)
+	mmb.setTimeoutField(timeoutField)
+
+	m.fileBuilder.AppendDeclaration(mmb)
+	return nil
+}
+
+type constructorBuilder struct {
+	timeoutPackageName   string
+	timePackageName      string
+	interfacePackageName string
+	interfaceName        string
+	structName           string
+
+	methodNames   []string
+	timeoutFields []string
+}
+
+func newConstructorBuilder(timeoutPackageName, timePackageName, packageName, interfaceName, structName string) *constructorBuilder {
+	return &constructorBuilder{
+		timeoutPackageName:   timeoutPackageName,
+		timePackageName:      timePackageName,
+		interfacePackageName: packageName,
+		interfaceName:        interfaceName,
+		structName:           structName,
+	}
+}
+
+func (c *constructorBuilder) addTimedMethod(methodName, timeoutField string) {
+	c.methodNames = append(c.methodNames, methodName)
+	c.timeoutFields = append(c.timeoutFields, timeoutField)
+}
+
+// Build builds the constructor, which obtains the timeouts of all timed
+// methods upfront:
+//   timeouts := timeout.NewTimeouts(defaultTimeout, opts...)
+//   return &timeoutService{
+//     next:          next,
+//     doWorkTimeout: timeouts.Timeout("DoWork"),
+//   }
+func (c *constructorBuilder) Build() ast.Decl {
+	elts := []ast.Expr{
+		&ast.KeyValueExpr{
+			Key:   ast.NewIdent("next"),
+			Value: ast.NewIdent("next"),
+		},
+	}
+	for i, methodName := range c.methodNames {
+		elts = append(elts, &ast.KeyValueExpr{
+			Key: ast.NewIdent(c.timeoutFields[i]),
+			Value: &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   ast.NewIdent("timeouts"),
+					Sel: ast.NewIdent("Timeout"),
+				},
+				Args: []ast.Expr{
+					&ast.BasicLit{Kind: token.STRING, Value: fmt.Sprintf("%q", methodName)},
+				},
+			},
+		})
+	}
+
+	var stmts []ast.Stmt
+	if len(c.methodNames) > 0 {
+		stmts = append(stmts, &ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent("timeouts")},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   ast.NewIdent(c.timeoutPackageName),
+						Sel: ast.NewIdent("NewTimeouts"),
+					},
+					Args:     []ast.Expr{ast.NewIdent("defaultTimeout"), ast.NewIdent("opts")},
+					Ellipsis: 1,
+				},
+			},
+		})
+	}
+	stmts = append(stmts, &ast.ReturnStmt{
+		Results: []ast.Expr{
+			&ast.UnaryExpr{
+				Op: token.AND,
+				X: &ast.CompositeLit{
+					Type: ast.NewIdent(c.structName),
+					Elts: elts,
+				},
+			},
+		},
+	})
+
+	funcName := fmt.Sprintf("NewTimeout%s", c.interfaceName)
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{
+			List: []*ast.Comment{
+				{
+					Text: fmt.Sprintf("// %s creates new timeout enforcing middleware.", funcName),
+				},
+			},
+		},
+		Name: ast.NewIdent(funcName),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("next")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interfacePackageName),
+							Sel: ast.NewIdent(c.interfaceName),
+						},
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("defaultTimeout")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.timePackageName),
+							Sel: ast.NewIdent("Duration"),
+						},
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("opts")},
+						Type: &ast.Ellipsis{
+							Elt: &ast.SelectorExpr{
+								X:   ast.NewIdent(c.timeoutPackageName),
+								Sel: ast.NewIdent("Option"),
+							},
+						},
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interfacePackageName),
+							Sel: ast.NewIdent(c.interfaceName),
+						},
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{List: stmts},
+	}
+}
+
+// timeoutMethodBuilder is responsible for creating a method that implements
+// the original method from the interface and enforces a timeout on the calls
+// to it.
+type timeoutMethodBuilder struct {
+	methodConfig        *astgen.MethodConfig
+	method              *astgen.Method
+	timeoutPackageAlias string
+	timePackageAlias    string
+	timeoutField        string
+	ctxArgName          string
+	timeoutResult       string
+	scope               *astgen.Scope
+}
+
+func newTimeoutMethodBuilder(structName string, methodConfig *astgen.MethodConfig, timeoutPackageAlias, timePackageAlias string, scope *astgen.Scope) *timeoutMethodBuilder {
+	method := astgen.NewMethod(methodConfig.MethodName, "m", structName)
+
+	return &timeoutMethodBuilder{
+		methodConfig:        methodConfig,
+		method:              method,
+		timeoutPackageAlias: timeoutPackageAlias,
+		timePackageAlias:    timePackageAlias,
+		scope:               scope,
+	}
+}
+
+func (b *timeoutMethodBuilder) setTimeoutField(name string) {
+	b.timeoutField = name
+}
+
+// setContextArgName makes the method enforce the timeout through the
+// context argument with the specified name.
+func (b *timeoutMethodBuilder) setContextArgName(name string) {
+	b.ctxArgName = name
+}
+
+// setTimeoutResult makes the method enforce the timeout by abandoning the
+// call and reporting a timeout error through the result with the specified
+// name.
+func (b *timeoutMethodBuilder) setTimeoutResult(name string) {
+	b.timeoutResult = name
+}
+
+func (b *timeoutMethodBuilder) Build() ast.Decl {
+	b.method.SetType(&ast.FuncType{
+		Params: &ast.FieldList{
+			List: b.methodConfig.MethodParams,
+		},
+		Results: &ast.FieldList{
+			List: transformation.FieldsAsAnonymous(b.methodConfig.MethodResults),
+		},
+	})
+
+	methodInvocation := astgen.NewMethodInvocation(b.methodConfig)
+	methodInvocation.SetReceiver(&ast.SelectorExpr{
+		X:   ast.NewIdent("m"), // receiver name
+		Sel: ast.NewIdent("next"),
+	})
+
+	switch {
+	case b.timeoutField == "":
+		// Methods that can neither be cancelled nor report a timeout are
+		// proxied:
+		//   return m.next.Method(arg1, arg2)
+		b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf("%s calls the wrapped method.", b.methodConfig.MethodName)))
+		b.method.AddStatement(methodInvocation.BuildReturn())
+	case b.ctxArgName != "":
+		b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf("%s calls the wrapped method with a context that times out.", b.methodConfig.MethodName)))
+		b.method.AddStatements(b.contextTimeoutStmts())
+		b.method.AddStatement(methodInvocation.BuildReturn())
+	default:
+		b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf(goroutineDoc, b.methodConfig.MethodName)))
+		b.method.AddStatements(b.goroutineTimeoutStmts(methodInvocation))
+	}
+
+	return b.method.Build()
+}
+
+// contextTimeoutStmts builds the statements which derive a context with the
+// timeout of the method:
+//   ctx, cancel := timeout.Context(ctx, m.doWorkTimeout)
+//   defer cancel()
+func (b *timeoutMethodBuilder) contextTimeoutStmts() []ast.Stmt {
+	cancel := b.scope.Name("cancel")
+	return []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(b.ctxArgName), ast.NewIdent(cancel)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   ast.NewIdent(b.timeoutPackageAlias),
+						Sel: ast.NewIdent("Context"),
+					},
+					Args: []ast.Expr{ast.NewIdent(b.ctxArgName), b.timeoutSelector()},
+				},
+			},
+		},
+		&ast.DeferStmt{
+			Call: &ast.CallExpr{Fun: ast.NewIdent(cancel)},
+		},
+	}
+}
+
+// goroutineTimeoutStmts builds the statements which run the call in a
+// separate goroutine and abandon it once the timeout of the method elapses:
+//   if m.twoTimeout <= 0 {
+//     return m.next.Two()
+//   }
+//   var result1 string
+//   var result2 error
+//   done := make(chan struct{})
+//   go func() {
+//     defer close(done)
+//     result1, result2 = m.next.Two()
+//   }()
+//   timer := time.NewTimer(m.twoTimeout)
+//   defer timer.Stop()
+//   select {
+//   case <-done:
+//     return result1, result2
+//   case <-timer.C:
+//     var result1 string
+//     var result2 error
+//     result2 = &timeout.TimeoutError{Method: "Two", Duration: m.twoTimeout}
+//     return result1, result2
+//   }
+// The results of an abandoned call are shadowed, so that they are not read
+// while the goroutine may still be writing them.
+func (b *timeoutMethodBuilder) goroutineTimeoutStmts(methodInvocation *astgen.MethodInvocation) []ast.Stmt {
+	var (
+		done  = b.scope.Name("done")
+		timer = b.scope.Name("timer")
+	)
+
+	var stmts []ast.Stmt
+	stmts = append(stmts, &ast.IfStmt{
+		Cond: &ast.BinaryExpr{
+			X:  b.timeoutSelector(),
+			Op: token.LEQ,
+			Y:  &ast.BasicLit{Kind: token.INT, Value: "0"},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{methodInvocation.BuildReturn()},
+		},
+	})
+	stmts = append(stmts, astgen.NewDeclareResults(b.methodConfig).Build()...)
+	stmts = append(stmts,
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(done)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: ast.NewIdent("make"),
+					Args: []ast.Expr{
+						&ast.ChanType{
+							Dir:   ast.SEND | ast.RECV,
+							Value: &ast.StructType{Fields: &ast.FieldList{Opening: 1, Closing: 1}},
+						},
+					},
+				},
+			},
+		},
+		&ast.GoStmt{
+			Call: &ast.CallExpr{
+				Fun: &ast.FuncLit{
+					Type: &ast.FuncType{Params: &ast.FieldList{}},
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.DeferStmt{
+								Call: &ast.CallExpr{
+									Fun:  ast.NewIdent("close"),
+									Args: []ast.Expr{ast.NewIdent(done)},
+								},
+							},
+							methodInvocation.BuildAssign(),
+						},
+					},
+				},
+			},
+		},
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(timer)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   ast.NewIdent(b.timePackageAlias),
+						Sel: ast.NewIdent("NewTimer"),
+					},
+					Args: []ast.Expr{b.timeoutSelector()},
+				},
+			},
+		},
+		&ast.DeferStmt{
+			Call: &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   ast.NewIdent(timer),
+					Sel: ast.NewIdent("Stop"),
+				},
+			},
+		},
+	)
+
+	timedOutStmts := astgen.NewDeclareResults(b.methodConfig).Build()
+	timedOutStmts = append(timedOutStmts,
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(b.timeoutResult)},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{
+				&ast.UnaryExpr{
+					Op: token.AND,
+					X: &ast.CompositeLit{
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(b.timeoutPackageAlias),
+							Sel: ast.NewIdent("TimeoutError"),
+						},
+						Elts: []ast.Expr{
+							&ast.KeyValueExpr{
+								Key: ast.NewIdent("Method"),
+								Value: &ast.BasicLit{
+									Kind:  token.STRING,
+									Value: fmt.Sprintf("%q", b.methodConfig.MethodName),
+								},
+							},
+							&ast.KeyValueExpr{
+								Key:   ast.NewIdent("Duration"),
+								Value: b.timeoutSelector(),
+							},
+						},
+					},
+				},
+			},
+		},
+		astgen.NewReturnResults(b.methodConfig).Build(),
+	)
+
+	stmts = append(stmts, &ast.SelectStmt{
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.CommClause{
+					Comm: &ast.ExprStmt{
+						X: &ast.UnaryExpr{Op: token.ARROW, X: ast.NewIdent(done)},
+					},
+					Body: []ast.Stmt{astgen.NewReturnResults(b.methodConfig).Build()},
+				},
+				&ast.CommClause{
+					Comm: &ast.ExprStmt{
+						X: &ast.UnaryExpr{
+							Op: token.ARROW,
+							X: &ast.SelectorExpr{
+								X:   ast.NewIdent(timer),
+								Sel: ast.NewIdent("C"),
+							},
+						},
+					},
+					Body: timedOutStmts,
+				},
+			},
+		},
+	})
+	return stmts
+}
+
+func (b *timeoutMethodBuilder) timeoutSelector() ast.Expr {
+	return &ast.SelectorExpr{
+		X:   ast.NewIdent("m"), // receiver name
+		Sel: ast.NewIdent(b.timeoutField),
+	}
+}
@@ -0,0 +1,25 @@
+package timeoutgen
+
+import (
+	"fmt"
+
+	"github.com/Bo0mer/gentools/pkg/plugin"
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+// Plugin generates timeout implementations of interfaces.
+var Plugin = plugin.Plugin{
+	Name:        "timeoutgen",
+	Description: "timeout",
+	FileName: func(cfg plugin.Config) string {
+		return fmt.Sprintf("timeout_%s.go", transformation.ToSnakeCase(cfg.InterfaceName))
+	},
+	NewModel: func(cfg plugin.Config) (plugin.Model, error) {
+		typeName := fmt.Sprintf("timeout%s", cfg.InterfaceName)
+		return newModel(cfg.InterfacePath, cfg.InterfaceName, typeName, cfg.TargetPackage), nil
+	},
+}
+
+func init() {
+	plugin.Register(Plugin)
+}
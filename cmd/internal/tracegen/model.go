@@ -1,12 +1,10 @@
-package main
+package tracegen
 
 import (
 	"fmt"
 	"go/ast"
-	"go/format"
-	"go/token"
-	"io"
 
+	"github.com/Bo0mer/gentools/cmd/internal/tracing"
 	"github.com/Bo0mer/gentools/pkg/astgen"
 	"github.com/Bo0mer/gentools/pkg/transformation"
 )
@@ -33,7 +31,8 @@ func newModel(interfacePath, interfaceName, structName, targetPkg string) *model
 		structName:    structName,
 	}
 	sourcePackageAlias := m.AddImport("", interfacePath)
-	m.tracePackageAlias = m.AddImport("", "go.opencensus.io/trace")
+
+	file.AppendDeclaration(astgen.NewInterfaceAssertion(sourcePackageAlias, interfaceName, structName))
 
 	constructorBuilder := newConstructorBuilder(sourcePackageAlias, interfaceName)
 	file.AppendDeclaration(constructorBuilder)
@@ -43,14 +42,8 @@ func newModel(interfacePath, interfaceName, structName, targetPkg string) *model
 	return m
 }
 
-func (m *model) WriteSource(w io.Writer) error {
-	fmt.Fprintf(w, "// Code generated by tracegen. DO NOT EDIT.\n")
-	astFile := m.fileBuilder.Build()
-
-	if err := format.Node(w, token.NewFileSet(), astFile); err != nil {
-		return err
-	}
-	return nil
+func (m *model) Build() *ast.File {
+	return m.fileBuilder.Build()
 }
 
 func (m *model) AddImport(pkgName, location string) string {
@@ -62,8 +55,13 @@ func (m *model) AddImport(pkgName, location string) string {
 }
 
 func (m *model) AddMethod(method *astgen.MethodConfig) error {
+	// The trace package is imported by the first traced method, as it would
+	// be left unused by interfaces without any.
+	if _, traced := method.ContextParamName(m.contextPackageAlias); traced && m.tracePackageAlias == "" {
+		m.tracePackageAlias = m.AddImport("", "go.opencensus.io/trace")
+	}
 	fullMethodName := fmt.Sprintf("%s.%s.%s", m.interfacePath, m.interfaceName, method.MethodName)
-	mmb := newTracingMethodBuilder(m.structName, method, m.tracePackageAlias, m.contextPackageAlias, fullMethodName)
+	mmb := newTracingMethodBuilder(m.structName, method, m.tracePackageAlias, m.contextPackageAlias, fullMethodName, m.fileBuilder.MethodScope(method, "m"))
 
 	m.fileBuilder.AppendDeclaration(mmb)
 	return nil
@@ -143,9 +141,10 @@ type tracingMethodBuilder struct {
 	method              *astgen.Method
 	tracePackageAlias   string
 	contextPackageAlias string
+	scope               *astgen.Scope
 }
 
-func newTracingMethodBuilder(structName string, methodConfig *astgen.MethodConfig, tracePackageAlias, contextPackageAlias, fullMethodName string) *tracingMethodBuilder {
+func newTracingMethodBuilder(structName string, methodConfig *astgen.MethodConfig, tracePackageAlias, contextPackageAlias, fullMethodName string, scope *astgen.Scope) *tracingMethodBuilder {
 	method := astgen.NewMethod(methodConfig.MethodName, "m", structName)
 
 	return &tracingMethodBuilder{
@@ -154,6 +153,7 @@ func newTracingMethodBuilder(structName string, methodConfig *astgen.MethodConfi
 		method:              method,
 		tracePackageAlias:   tracePackageAlias,
 		contextPackageAlias: contextPackageAlias,
+		scope:               scope,
 	}
 }
 func (b *tracingMethodBuilder) Build() ast.Decl {
@@ -169,115 +169,51 @@ func (b *tracingMethodBuilder) Build() ast.Decl {
 	// If the first parameter is context, add tracing call.
 	//   ctx, span := trace.StartSpan(ctx, "github.com/pkg.Component.Method")
 	//   defer span.End()
-	if len(b.methodConfig.MethodParams) > 0 {
-		p1 := b.methodConfig.MethodParams[0]
-		if sel, ok := p1.Type.(*ast.SelectorExpr); ok {
-			if sel.Sel.String() == "Context" {
-				if id, ok := sel.X.(*ast.Ident); ok && id.String() == b.contextPackageAlias {
-					b.method.AddStatement(
-						newTraceMethodInvocation(b.tracePackageAlias,
-							p1.Names[0].Name, b.fullMethodName))
-
-					b.method.AddStatement(newEndSpanStmt())
+	traced := false
+	spanVarName := ""
+	if ctxArgName, ok := b.methodConfig.ContextParamName(b.contextPackageAlias); ok {
+		spanVarName = b.scope.Name(tracing.SpanVarName)
+		b.method.AddStatement(
+			tracing.StartSpan(b.tracePackageAlias,
+				ctxArgName, spanVarName, b.fullMethodName))
 
-				}
-			}
-		}
+		b.method.AddStatement(tracing.EndSpan(spanVarName))
+		traced = true
 	}
+	summary := "%s calls the wrapped method."
+	if traced {
+		summary = "%s calls the wrapped method in a new span."
+	}
+	b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf(summary, b.methodConfig.MethodName)))
 
 	// Add method invocation:
-	//   result1, result2 := m.next.Method(arg1, arg2)
-	methodInvocation := NewMethodInvocation(b.methodConfig)
+	//   return m.next.Method(arg1, arg2)
+	methodInvocation := astgen.NewMethodInvocation(b.methodConfig)
 	methodInvocation.SetReceiver(&ast.SelectorExpr{
 		X:   ast.NewIdent("m"), // receiver name
 		Sel: ast.NewIdent("next"),
 	})
-	b.method.AddStatement(methodInvocation.Build())
-
-	return b.method.Build()
-}
-
-func newEndSpanStmt() ast.Stmt {
-	callExpr := &ast.CallExpr{
-		Fun: &ast.SelectorExpr{
-			X:   ast.NewIdent("_span"),
-			Sel: ast.NewIdent("End"),
-		},
+	if !traced || b.methodConfig.FailureCondition == nil {
+		b.method.AddStatement(methodInvocation.BuildReturn())
+		return b.method.Build()
 	}
 
-	return &ast.DeferStmt{Call: callExpr}
-}
-
-func newTraceMethodInvocation(tracePackageAlias, contextParamName, fullMethodName string) ast.Stmt {
-	paramSelectors := []ast.Expr{
-		ast.NewIdent(contextParamName),
-		&ast.BasicLit{
-			Kind:  token.STRING,
-			Value: fmt.Sprintf("%q", fullMethodName),
-		},
-	}
-	callExpr := &ast.CallExpr{
-		Fun: &ast.SelectorExpr{
-			X:   ast.NewIdent(tracePackageAlias),
-			Sel: ast.NewIdent("StartSpan"),
-		},
-		Args: paramSelectors,
-	}
-
-	resultSelectors := []ast.Expr{
-		ast.NewIdent(contextParamName),
-		ast.NewIdent("_span"),
-	}
-
-	return &ast.AssignStmt{
-		Lhs: resultSelectors,
-		Tok: token.DEFINE,
-		Rhs: []ast.Expr{
-			callExpr,
-		},
-	}
-}
-
-// TODO: Move MethodInvocation to a reusable package as
-// the same implementation can be seen multiple times
-// within this project.
-
-type MethodInvocation struct {
-	receiver *ast.SelectorExpr
-	method   *astgen.MethodConfig
-}
-
-func (m *MethodInvocation) SetReceiver(s *ast.SelectorExpr) {
-	m.receiver = s
-}
-
-func NewMethodInvocation(method *astgen.MethodConfig) *MethodInvocation {
-	return &MethodInvocation{method: method}
-}
-
-func (m *MethodInvocation) Build() ast.Stmt {
-	var paramSelectors []ast.Expr
-	ellipsisPos := token.NoPos
-	for _, param := range m.method.MethodParams {
-		paramSelectors = append(paramSelectors, ast.NewIdent(param.Names[0].String()))
-		if p, ok := param.Type.(*ast.Ellipsis); ok {
-			ellipsisPos = p.Pos()
-		}
-	}
-
-	callExpr := &ast.CallExpr{
-		Fun: &ast.SelectorExpr{
-			X:   m.receiver,
-			Sel: ast.NewIdent(m.method.MethodName),
+	// Mark the span as failed if the call has failed:
+	//   result1, result2 := m.next.Method(arg1, arg2)
+	//   if [failure condition] {
+	//     _span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: result2.Error()})
+	//   }
+	//   return result1, result2
+	b.method.AddStatement(methodInvocation.Build())
+	b.method.AddStatement(&ast.IfStmt{
+		Cond: b.methodConfig.FailureCondition,
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				tracing.SetSpanStatus(b.tracePackageAlias, spanVarName, tracing.FailureMessage(b.methodConfig)),
+			},
 		},
-		Args:     paramSelectors,
-		Ellipsis: ellipsisPos,
-	}
+	})
+	b.method.AddStatement(astgen.NewReturnResults(b.methodConfig).Build())
 
-	if m.method.HasResults() {
-		return &ast.ReturnStmt{
-			Results: []ast.Expr{callExpr},
-		}
-	}
-	return &ast.ExprStmt{X: callExpr}
+	return b.method.Build()
 }
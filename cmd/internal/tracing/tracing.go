@@ -0,0 +1,137 @@
+// Package tracing provides the builders of the opencensus tracing statements
+// shared by the generators.
+package tracing
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/Bo0mer/gentools/pkg/astgen"
+)
+
+// SpanVarName is the name of the variable holding the span of a call, unless
+// it is taken in the scope of the method.
+const SpanVarName = "_span"
+
+// StartSpan builds a statement that starts a new span, named after the full
+// name of the method, and replaces the context with the one holding it:
+//   ctx, _span := trace.StartSpan(ctx, "github.com/pkg.Component.Method")
+func StartSpan(tracePackageAlias, contextParamName, spanVarName, fullMethodName string) ast.Stmt {
+	paramSelectors := []ast.Expr{
+		ast.NewIdent(contextParamName),
+		&ast.BasicLit{
+			Kind:  token.STRING,
+			Value: fmt.Sprintf("%q", fullMethodName),
+		},
+	}
+	callExpr := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent(tracePackageAlias),
+			Sel: ast.NewIdent("StartSpan"),
+		},
+		Args: paramSelectors,
+	}
+
+	resultSelectors := []ast.Expr{
+		ast.NewIdent(contextParamName),
+		ast.NewIdent(spanVarName),
+	}
+
+	return &ast.AssignStmt{
+		Lhs: resultSelectors,
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			callExpr,
+		},
+	}
+}
+
+// EndSpan builds a statement that ends the span once the method returns:
+//   defer _span.End()
+func EndSpan(spanVarName string) ast.Stmt {
+	callExpr := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent(spanVarName),
+			Sel: ast.NewIdent("End"),
+		},
+	}
+
+	return &ast.DeferStmt{Call: callExpr}
+}
+
+// SetSpanStatus builds a statement that marks the span as failed with the
+// specified message:
+//   _span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: result2.Error()})
+func SetSpanStatus(tracePackageAlias, spanVarName string, message ast.Expr) ast.Stmt {
+	status := &ast.CompositeLit{
+		Type: &ast.SelectorExpr{
+			X:   ast.NewIdent(tracePackageAlias),
+			Sel: ast.NewIdent("Status"),
+		},
+		Elts: []ast.Expr{
+			&ast.KeyValueExpr{
+				Key: ast.NewIdent("Code"),
+				Value: &ast.SelectorExpr{
+					X:   ast.NewIdent(tracePackageAlias),
+					Sel: ast.NewIdent("StatusCodeUnknown"),
+				},
+			},
+			&ast.KeyValueExpr{
+				Key:   ast.NewIdent("Message"),
+				Value: message,
+			},
+		},
+	}
+
+	callExpr := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent(spanVarName),
+			Sel: ast.NewIdent("SetStatus"),
+		},
+		Args: []ast.Expr{status},
+	}
+
+	return &ast.ExprStmt{X: callExpr}
+}
+
+// FailureMessage returns an expression describing the failure of a call. The
+// error is used only when the failure condition guarantees it is not nil.
+func FailureMessage(method *astgen.MethodConfig) ast.Expr {
+	errorResults := method.ErrorResults
+	if len(errorResults) == 1 && !method.HasCustomFailure() {
+		return &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   ast.NewIdent(errorResults[0].Names[0].Name),
+				Sel: ast.NewIdent("Error"),
+			},
+		}
+	}
+	return &ast.BasicLit{Kind: token.STRING, Value: `"operation failed"`}
+}
+
+// CorrelationFields returns the log fields which correlate a log line with
+// the span of the call:
+//   "trace_id", _span.SpanContext().TraceID.String(), "span_id", _span.SpanContext().SpanID.String()
+func CorrelationFields(spanVarName string) []ast.Expr {
+	idField := func(key, id string) []ast.Expr {
+		return []ast.Expr{
+			&ast.BasicLit{Kind: token.STRING, Value: fmt.Sprintf("%q", key)},
+			&ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X: &ast.SelectorExpr{
+						X: &ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   ast.NewIdent(spanVarName),
+								Sel: ast.NewIdent("SpanContext"),
+							},
+						},
+						Sel: ast.NewIdent(id),
+					},
+					Sel: ast.NewIdent("String"),
+				},
+			},
+		}
+	}
+	return append(idField("trace_id", "TraceID"), idField("span_id", "SpanID")...)
+}
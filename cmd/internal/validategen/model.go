@@ -0,0 +1,223 @@
+package validategen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/Bo0mer/gentools/pkg/astgen"
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+type model struct {
+	fileBuilder *astgen.File
+	structName  string
+}
+
+func newModel(interfacePath, interfaceName, structName, targetPkg string) *model {
+	file := astgen.NewFile(targetPkg)
+	strct := astgen.NewStruct(structName)
+	file.AppendDeclaration(strct)
+
+	m := &model{
+		fileBuilder: file,
+		structName:  structName,
+	}
+	sourcePackageAlias := m.AddImport("", interfacePath)
+
+	file.AppendDeclaration(astgen.NewInterfaceAssertion(sourcePackageAlias, interfaceName, structName))
+
+	constructorBuilder := newConstructorBuilder(sourcePackageAlias, interfaceName, structName)
+	file.AppendDeclaration(constructorBuilder)
+
+	strct.AddField("next", sourcePackageAlias, interfaceName)
+
+	return m
+}
+
+func (m *model) Build() *ast.File {
+	return m.fileBuilder.Build()
+}
+
+func (m *model) AddImport(pkgName, location string) string {
+	return m.fileBuilder.AddImport(pkgName, location)
+}
+
+func (m *model) AddMethod(method *astgen.MethodConfig) error {
+	mmb := newValidatingMethodBuilder(m.structName, method)
+	m.fileBuilder.AppendDeclaration(mmb)
+	return nil
+}
+
+type constructorBuilder struct {
+	interfacePackageName string
+	interfaceName        string
+	structName           string
+}
+
+func newConstructorBuilder(packageName, interfaceName, structName string) *constructorBuilder {
+	return &constructorBuilder{
+		interfacePackageName: packageName,
+		interfaceName:        interfaceName,
+		structName:           structName,
+	}
+}
+
+func (c *constructorBuilder) Build() ast.Decl {
+	funcBody := &ast.BlockStmt{
+		List: []ast.Stmt{
+			&ast.ReturnStmt{
+				Results: []ast.Expr{
+					&ast.UnaryExpr{
+						Op: token.AND,
+						X: &ast.CompositeLit{
+							Type: ast.NewIdent(c.structName),
+							Elts: []ast.Expr{
+								ast.NewIdent("next"),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	funcName := fmt.Sprintf("NewValidating%s", c.interfaceName)
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{
+			List: []*ast.Comment{
+				{
+					Text: fmt.Sprintf("// %s creates new input validating middleware.", funcName),
+				},
+			},
+		},
+		Name: ast.NewIdent(funcName),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("next")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interfacePackageName),
+							Sel: ast.NewIdent(c.interfaceName),
+						},
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent(c.interfacePackageName),
+							Sel: ast.NewIdent(c.interfaceName),
+						},
+					},
+				},
+			},
+		},
+		Body: funcBody,
+	}
+}
+
+// validatingMethodBuilder is responsible for creating a method that
+// implements the original method from the interface and validates the
+// arguments before delegating the call.
+type validatingMethodBuilder struct {
+	methodConfig *astgen.MethodConfig
+	method       *astgen.Method
+}
+
+func newValidatingMethodBuilder(structName string, methodConfig *astgen.MethodConfig) *validatingMethodBuilder {
+	method := astgen.NewMethod(methodConfig.MethodName, "m", structName)
+
+	return &validatingMethodBuilder{
+		methodConfig: methodConfig,
+		method:       method,
+	}
+}
+
+func (b *validatingMethodBuilder) Build() ast.Decl {
+	b.method.SetType(&ast.FuncType{
+		Params: &ast.FieldList{
+			List: b.methodConfig.MethodParams,
+		},
+		Results: &ast.FieldList{
+			List: transformation.FieldsAsAnonymous(b.methodConfig.MethodResults),
+		},
+	})
+
+	// Validation errors can only be reported through an error result.
+	summary := "%s calls the wrapped method."
+	errorResult, ok := b.methodConfig.ErrorResultName()
+	if ok && len(b.methodConfig.ValidatorParams) > 0 {
+		summary = "%s validates the arguments of the call before calling the wrapped method."
+		// Declare the results, so that invalid calls return zero values:
+		//   var result1 string
+		//   var result2 error
+		b.method.AddStatements(astgen.NewDeclareResults(b.methodConfig).Build())
+		for _, param := range b.methodConfig.ValidatorParams {
+			b.method.AddStatement(b.validateStmt(param, errorResult))
+		}
+	}
+
+	// Add method invocation:
+	//   return m.next.Method(arg1, arg2)
+	methodInvocation := astgen.NewMethodInvocation(b.methodConfig)
+	methodInvocation.SetReceiver(&ast.SelectorExpr{
+		X:   ast.NewIdent("m"), // receiver name
+		Sel: ast.NewIdent("next"),
+	})
+	b.method.AddStatement(methodInvocation.BuildReturn())
+
+	b.method.SetDoc(b.methodConfig.WrapperDoc(fmt.Sprintf(summary, b.methodConfig.MethodName)))
+	return b.method.Build()
+}
+
+// validateStmt builds the statement which validates the specified argument
+// and returns the validation error, if any:
+//   if result2 = arg2.Validate(); result2 != nil {
+//     return result1, result2
+//   }
+// Pointer arguments are validated only if they are not nil:
+//   if arg2 != nil {
+//     if result2 = arg2.Validate(); result2 != nil {
+//       return result1, result2
+//     }
+//   }
+func (b *validatingMethodBuilder) validateStmt(param *ast.Field, errorResult string) ast.Stmt {
+	argName := param.Names[0].String()
+	var stmt ast.Stmt = &ast.IfStmt{
+		Init: &ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(errorResult)},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   ast.NewIdent(argName),
+						Sel: ast.NewIdent("Validate"),
+					},
+				},
+			},
+		},
+		Cond: &ast.BinaryExpr{
+			X:  ast.NewIdent(errorResult),
+			Op: token.NEQ,
+			Y:  ast.NewIdent("nil"),
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{astgen.NewReturnResults(b.methodConfig).Build()},
+		},
+	}
+
+	if _, ok := param.Type.(*ast.StarExpr); ok {
+		stmt = &ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X:  ast.NewIdent(argName),
+				Op: token.NEQ,
+				Y:  ast.NewIdent("nil"),
+			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{stmt}},
+		}
+	}
+	return stmt
+}
@@ -5,11 +5,12 @@ go 1.17
 require (
 	github.com/go-kit/kit v0.11.0
 	go.opencensus.io v0.23.0
+	golang.org/x/tools v0.1.8
 )
 
 require (
+	github.com/go-logfmt/logfmt v0.5.0 // indirect
 	golang.org/x/mod v0.5.1 // indirect
 	golang.org/x/sys v0.0.0-20211019181941-9d821ace8654 // indirect
-	golang.org/x/tools v0.1.8 // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 )
@@ -0,0 +1,60 @@
+package astgen
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// AnnotationPrefix is the prefix of the comment lines which are treated as
+// gentools annotations. Annotations are written as Go directives in the doc
+// comment of an interface method, e.g.
+//   //gentools:failure 2
+const AnnotationPrefix = "gentools:"
+
+// FailureAnnotation overrides how a call to a method is determined to have
+// failed. Its value is either the 1-based index of a bool or nilable result
+// (bool results indicate failure when false, all others when non-nil) or a
+// reference to a func(results...) bool predicate, e.g.
+//   //gentools:failure 2
+//   //gentools:failure IsFailure
+//   //gentools:failure status.IsFailure
+const FailureAnnotation = "failure"
+
+// Annotations holds the gentools annotations of a declaration, keyed by
+// their names.
+type Annotations map[string]string
+
+// ParseAnnotations extracts all gentools annotations from the provided doc
+// comment.
+func ParseAnnotations(doc *ast.CommentGroup) Annotations {
+	annotations := Annotations{}
+	if doc == nil {
+		return annotations
+	}
+	for _, comment := range doc.List {
+		text := strings.TrimPrefix(comment.Text, "//")
+		if !strings.HasPrefix(text, AnnotationPrefix) {
+			continue
+		}
+		text = strings.TrimPrefix(text, AnnotationPrefix)
+		name, value := text, ""
+		if i := strings.IndexAny(text, " \t"); i >= 0 {
+			name, value = text[:i], strings.TrimSpace(text[i:])
+		}
+		annotations[name] = value
+	}
+	return annotations
+}
+
+// Has returns whether an annotation with the specified name is present.
+func (a Annotations) Has(name string) bool {
+	_, ok := a[name]
+	return ok
+}
+
+// Lookup returns the value of the annotation with the specified name and
+// whether it is present at all.
+func (a Annotations) Lookup(name string) (string, bool) {
+	value, ok := a[name]
+	return value, ok
+}
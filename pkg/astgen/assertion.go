@@ -0,0 +1,49 @@
+package astgen
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// InterfaceAssertion represents a compile-time assertion that a struct
+// implements an interface.
+type InterfaceAssertion struct {
+	interfacePackageAlias string
+	interfaceName         string
+	structName            string
+}
+
+// NewInterfaceAssertion creates an assertion that pointers to the struct
+// implement the interface, imported under the specified alias.
+func NewInterfaceAssertion(interfacePackageAlias, interfaceName, structName string) *InterfaceAssertion {
+	return &InterfaceAssertion{
+		interfacePackageAlias: interfacePackageAlias,
+		interfaceName:         interfaceName,
+		structName:            structName,
+	}
+}
+
+// Build builds the assertion:
+//   var _ alias1.Service = (*monitoringService)(nil)
+func (a *InterfaceAssertion) Build() ast.Decl {
+	return &ast.GenDecl{
+		Tok: token.VAR,
+		Specs: []ast.Spec{
+			&ast.ValueSpec{
+				Names: []*ast.Ident{ast.NewIdent("_")},
+				Type: &ast.SelectorExpr{
+					X:   ast.NewIdent(a.interfacePackageAlias),
+					Sel: ast.NewIdent(a.interfaceName),
+				},
+				Values: []ast.Expr{
+					&ast.CallExpr{
+						Fun: &ast.ParenExpr{
+							X: &ast.StarExpr{X: ast.NewIdent(a.structName)},
+						},
+						Args: []ast.Expr{ast.NewIdent("nil")},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,47 @@
+package astgen
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// DeprecatedPrefix starts the paragraph of a doc comment which marks the
+// documented declaration as deprecated, e.g.
+//   // Deprecated: Use Find instead.
+const DeprecatedPrefix = "Deprecated: "
+
+// ParseDoc returns the text of the provided doc comment, without the
+// gentools annotations, along with its deprecation notice, if any.
+func ParseDoc(doc *ast.CommentGroup) (text, deprecated string) {
+	if doc == nil {
+		return "", ""
+	}
+	withoutAnnotations := &ast.CommentGroup{}
+	for _, comment := range doc.List {
+		if !strings.HasPrefix(strings.TrimPrefix(comment.Text, "//"), AnnotationPrefix) {
+			withoutAnnotations.List = append(withoutAnnotations.List, comment)
+		}
+	}
+	if len(withoutAnnotations.List) == 0 {
+		return "", ""
+	}
+
+	text = strings.TrimSpace(withoutAnnotations.Text())
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		if strings.HasPrefix(paragraph, DeprecatedPrefix) {
+			deprecated = paragraph
+		}
+	}
+	return text, deprecated
+}
+
+// joinParagraphs joins the non-empty paragraphs of a doc comment.
+func joinParagraphs(paragraphs ...string) string {
+	var nonEmpty []string
+	for _, paragraph := range paragraphs {
+		if paragraph != "" {
+			nonEmpty = append(nonEmpty, paragraph)
+		}
+	}
+	return strings.Join(nonEmpty, "\n\n")
+}
@@ -17,6 +17,7 @@ type File struct {
 	aliasToImport map[string]string
 	aliasCounter  int
 	declarations  []DeclarationBuilder
+	scope         *Scope
 }
 
 // NewFile returns new empty source file within the specified package.
@@ -25,6 +26,7 @@ func NewFile(packageName string) *File {
 		packageName:   packageName,
 		importToAlias: map[string]string{},
 		aliasToImport: map[string]string{},
+		scope:         NewScope(nil),
 	}
 }
 
@@ -45,12 +47,34 @@ func (f *File) AddImport(packageName, location string) (importAlias string) {
 
 	f.importToAlias[location] = alias
 	f.aliasToImport[alias] = location
+	f.scope.Reserve(alias)
 	return alias
 }
 
 func (f *File) allocateUniqueAlias() string {
-	f.aliasCounter++
-	return fmt.Sprintf("alias%d", f.aliasCounter)
+	for {
+		f.aliasCounter++
+		alias := fmt.Sprintf("alias%d", f.aliasCounter)
+		if !f.scope.Has(alias) {
+			return alias
+		}
+	}
+}
+
+// Scope returns the scope of the file, in which the import aliases are
+// taken.
+func (f *File) Scope() *Scope {
+	return f.scope
+}
+
+// Imports returns the locations of all imports added so far, keyed by their
+// aliases.
+func (f *File) Imports() map[string]string {
+	imports := make(map[string]string, len(f.aliasToImport))
+	for alias, location := range f.aliasToImport {
+		imports[alias] = location
+	}
+	return imports
 }
 
 // Build returns AST representing the file.
@@ -1,9 +1,12 @@
 package astgen
 
 import (
-	"errors"
 	"fmt"
 	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strconv"
 
 	"github.com/Bo0mer/gentools/pkg/internal"
 	"github.com/Bo0mer/gentools/pkg/resolution"
@@ -18,14 +21,57 @@ type MethodConfig struct {
 	// MethodParams specifies all the parameters of the method.  They should
 	// have been normalized (i.e. no type reuse and no anonymous parameters)
 	// and resolved (i.e. all selector expressions resolved against the
-	// generated stub's new namespace)
+	// generated stub's new namespace). Anonymous parameters are named after
+	// their position, e.g. arg1. So are the ones whose names would collide
+	// with the generated code.
 	MethodParams []*ast.Field
 
+	// ComparableParams specifies the subset of MethodParams whose types are
+	// comparable, i.e. which can be used as map keys. Parameters of interface
+	// types are left out, as comparing them may panic.
+	ComparableParams []*ast.Field
+
+	// ValidatorParams specifies the subset of MethodParams whose types have
+	// a Validate() error method.
+	ValidatorParams []*ast.Field
+
 	// MethodResults specifies all the results of the method.  They should have
 	// been normalized (i.e. no type reuse and no anonymous results) and
 	// resolved (i.e. all selector expressions resolved against the generated
-	// stub's new namespace)
+	// stub's new namespace). Anonymous results are named after their
+	// position, e.g. result1.
 	MethodResults []*ast.Field
+
+	// Signature specifies the type of the method as declared, i.e. with the
+	// original names of its parameters and results, if any, and with their
+	// types resolved like the ones of MethodParams and MethodResults.
+	Signature *ast.FuncType
+
+	// Annotations specifies the gentools annotations found in the doc comment
+	// of the method.
+	Annotations Annotations
+
+	// Doc specifies the text of the doc comment of the method, without the
+	// gentools annotations. It is empty for methods that are not documented.
+	Doc string
+
+	// Deprecated specifies the deprecation notice found in the doc comment of
+	// the method, e.g. "Deprecated: Use Find instead.". It is empty for
+	// methods that are not deprecated.
+	Deprecated string
+
+	// ErrorResults specifies the subset of MethodResults whose types implement
+	// the error interface and can be nil, e.g. error, custom error interfaces
+	// and pointers to error implementations.
+	ErrorResults []*ast.Field
+
+	// FailureCondition specifies a boolean expression, in terms of the
+	// MethodResults, that reports whether a call to the method has failed.
+	// It is nil for methods that cannot fail.
+	FailureCondition ast.Expr
+
+	// Position specifies the position of the declaration of the method.
+	Position token.Position
 }
 
 func (s *MethodConfig) HasParams() bool {
@@ -36,6 +82,83 @@ func (s *MethodConfig) HasResults() bool {
 	return len(s.MethodResults) > 0
 }
 
+// WrapperDoc returns the doc comment of a method wrapping the method, which
+// starts with the summary of what the wrapper does, followed by the doc
+// comment of the method, e.g.
+//   Get traces the calls to the wrapped method.
+//
+//   Get returns the user with the specified id.
+//
+//   Deprecated: Use Find instead.
+func (s *MethodConfig) WrapperDoc(summary string) string {
+	return joinParagraphs(summary, s.Doc)
+}
+
+// DeprecatedDoc returns the doc comment, followed by the deprecation notice
+// of the method, if any. It documents generated methods that exist only
+// because of the method, e.g. the ones configuring the fake of the method.
+func (s *MethodConfig) DeprecatedDoc(doc string) string {
+	return joinParagraphs(doc, s.Deprecated)
+}
+
+// ContextParamName returns the name of the first parameter of the method if
+// it is a context.Context, where contextPackageAlias is the alias under which
+// the context package was imported.
+func (s *MethodConfig) ContextParamName(contextPackageAlias string) (string, bool) {
+	if len(s.MethodParams) == 0 || contextPackageAlias == "" {
+		return "", false
+	}
+
+	p1 := s.MethodParams[0]
+	if sel, ok := p1.Type.(*ast.SelectorExpr); ok {
+		if sel.Sel.String() == "Context" {
+			if id, ok := sel.X.(*ast.Ident); ok && id.String() == contextPackageAlias {
+				return p1.Names[0].Name, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// ErrorResultName returns the name of the first result of type error, which
+// unlike other error results can hold any error reported by a middleware.
+func (s *MethodConfig) ErrorResultName() (string, bool) {
+	for _, result := range s.MethodResults {
+		if id, ok := result.Type.(*ast.Ident); ok && id.Name == "error" {
+			return result.Names[0].String(), true
+		}
+	}
+	return "", false
+}
+
+// WrapError annotates the error found in the method, e.g. by a model
+// validating the methods once all of them are added, with the declaration
+// of the method.
+func (s *MethodConfig) WrapError(err error) error {
+	return &resolution.DeclarationError{
+		Pos:   s.Position,
+		Decls: []string{fmt.Sprintf("method '%s'", s.MethodName)},
+		Err:   err,
+	}
+}
+
+// SuccessCondition returns the negation of the failure condition, i.e. a
+// boolean expression which reports whether a call to the method has
+// succeeded, e.g. result2 == nil. It is nil for methods that cannot fail.
+func (s *MethodConfig) SuccessCondition() ast.Expr {
+	if s.FailureCondition == nil {
+		return nil
+	}
+	return negateExpr(s.FailureCondition)
+}
+
+// HasCustomFailure returns whether the failure condition of the method was
+// overridden by an annotation.
+func (s *MethodConfig) HasCustomFailure() bool {
+	return s.Annotations.Has(FailureAnnotation)
+}
+
 type ModelBuilder interface {
 	// AddMethod should add implementation for the specified method.
 	AddMethod(*MethodConfig) error
@@ -47,111 +170,298 @@ type Generator struct {
 	Resolver *resolution.Resolver
 }
 
-func (g *Generator) ProcessInterface(d resolution.TypeDiscovery) error {
-	context := resolution.NewASTFileLocatorContext(d.File, d.Location)
-	iFaceType, isIFace := d.Spec.Type.(*ast.InterfaceType)
-	if !isIFace {
-		return errors.New(fmt.Sprintf("type '%s' in '%s' is not interface!", d.Spec.Name.String(), d.Location))
-	}
-	for field := range internal.EachFieldInFieldList(iFaceType.Methods) {
-		var err error
-		switch t := field.Type.(type) {
-		case *ast.FuncType:
-			err = g.processMethod(context, field.Names[0].String(), t)
-		case *ast.Ident:
-			err = g.processSubInterfaceIdent(context, t)
-		case *ast.SelectorExpr:
-			err = g.processSubInterfaceSelector(context, t)
-		default:
-			return errors.New("Unknown statement in interface declaration.")
-		}
-		if err != nil {
-			return err
-		}
+// ProcessType adds the methods of the discovered type, which is either an
+// interface or a struct, to the model.
+func (g *Generator) ProcessType(d resolution.TypeDiscovery) error {
+	if _, isStruct := d.Spec.Type.(*ast.StructType); isStruct {
+		return g.ProcessStruct(d)
 	}
-	return nil
+	return g.ProcessInterface(d)
 }
 
-func (g *Generator) processMethod(context *resolution.LocatorContext, name string, funcType *ast.FuncType) error {
-	normalizedParams, err := g.getNormalizedParams(context, funcType)
+// ProcessStruct adds the exported methods of a pointer to the discovered
+// struct, including the promoted ones, to the model.
+func (g *Generator) ProcessStruct(d resolution.TypeDiscovery) error {
+	methods, err := g.Locator.FindMethodSet(d)
 	if err != nil {
-		return err
-	}
-	normalizedResults, err := g.getNormalizedResults(context, funcType)
-	if err != nil {
-		return err
+		return g.Locator.TypeError(d, err)
 	}
+	return g.Locator.TypeError(d, g.processMethods(methods))
+}
 
-	source := &MethodConfig{
-		MethodName:    name,
-		MethodParams:  normalizedParams,
-		MethodResults: normalizedResults,
+// ProcessInterface adds the methods of the discovered interface, including
+// the methods of the embedded interfaces, to the model. Methods declared by
+// several embedded interfaces are added only once.
+func (g *Generator) ProcessInterface(d resolution.TypeDiscovery) error {
+	if err := g.Resolver.CheckVisibility(d.Spec.Name.Name, d.Location); err != nil {
+		return g.Locator.TypeError(d, err)
 	}
-	err = g.Model.AddMethod(source)
+	methods, err := g.Locator.FindInterfaceMethods(d)
 	if err != nil {
-		return err
+		return g.Locator.TypeError(d, err)
+	}
+	return g.Locator.TypeError(d, g.processMethods(methods))
+}
+
+func (g *Generator) processMethods(methods []resolution.MethodDiscovery) error {
+	for _, method := range methods {
+		// Interfaces with unexported methods cannot be implemented by
+		// other packages.
+		err := g.Resolver.CheckVisibility(method.Name, method.Location)
+		if err == nil {
+			err = g.processMethod(method.Context(), method.Name, method.Doc, method.Type, g.Locator.Position(method.Pos))
+		}
+		if err != nil {
+			return g.Locator.MethodError(method, err)
+		}
 	}
 	return nil
 }
 
-func (g *Generator) processSubInterfaceIdent(context *resolution.LocatorContext, ident *ast.Ident) error {
-	discovery, err := g.Locator.FindIdentType(context, ident)
+func (g *Generator) processMethod(context *resolution.LocatorContext, name string, doc *ast.CommentGroup, funcType *ast.FuncType, position token.Position) error {
+	normalizedParams, comparableParams, validatorParams, err := g.getNormalizedParams(context, funcType)
 	if err != nil {
 		return err
 	}
-	err = g.ProcessInterface(discovery)
+	normalizedResults, errorResults, err := g.getNormalizedResults(context, funcType)
 	if err != nil {
 		return err
 	}
-	return nil
-}
 
-func (g *Generator) processSubInterfaceSelector(context *resolution.LocatorContext, selector *ast.SelectorExpr) error {
-	discovery, err := g.Locator.FindSelectorType(context, selector)
+	annotations := ParseAnnotations(doc)
+	docText, deprecated := ParseDoc(doc)
+	failureCondition, err := g.getFailureCondition(context, annotations, funcType, normalizedResults, errorResults)
 	if err != nil {
-		return err
+		// Only annotated methods can have invalid failure conditions.
+		return g.Locator.ErrorAt(doc, err)
+	}
+
+	source := &MethodConfig{
+		MethodName:       name,
+		MethodParams:     normalizedParams,
+		ComparableParams: comparableParams,
+		ValidatorParams:  validatorParams,
+		MethodResults:    normalizedResults,
+		Signature: &ast.FuncType{
+			Params:  declaredFields(funcType.Params, normalizedParams),
+			Results: declaredFields(funcType.Results, normalizedResults),
+		},
+		Annotations:      annotations,
+		Doc:              docText,
+		Deprecated:       deprecated,
+		ErrorResults:     errorResults,
+		FailureCondition: failureCondition,
+		Position:         position,
 	}
-	err = g.ProcessInterface(discovery)
+	err = g.Model.AddMethod(source)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (g *Generator) getNormalizedParams(context *resolution.LocatorContext, funcType *ast.FuncType) ([]*ast.Field, error) {
+// getNormalizedParams returns the normalized parameters of the method along
+// with the subsets of them which are comparable and which can be validated.
+func (g *Generator) getNormalizedParams(context *resolution.LocatorContext, funcType *ast.FuncType) ([]*ast.Field, []*ast.Field, []*ast.Field, error) {
 	normalizedParams := []*ast.Field{}
+	comparableParams := []*ast.Field{}
+	validatorParams := []*ast.Field{}
 	paramIndex := 1
 	for param := range internal.EachFieldInFieldList(funcType.Params) {
-		count := internal.FieldTypeReuseCount(param)
-		for i := 0; i < count; i++ {
-			fieldName := fmt.Sprintf("arg%d", paramIndex)
-			fieldType, err := g.Resolver.ResolveType(context, param.Type)
-			if err != nil {
-				return nil, err
-			}
-			normalizedParam := internal.CreateField(fieldName, fieldType)
+		// Type information is looked up in the interface's namespace, so it
+		// must happen before the type is resolved.
+		isComparable, err := g.Locator.IsComparable(context, param.Type)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		isValidator, err := g.Locator.IsValidator(context, param.Type)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		// Types shared by multiple parameters are resolved only once.
+		fieldType, err := g.Resolver.ResolveType(context, param.Type)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, name := range fieldNames(param) {
+			normalizedParam := internal.CreateField(fieldName(context, name, "arg", paramIndex), fieldType)
 			normalizedParams = append(normalizedParams, normalizedParam)
+			if isComparable {
+				comparableParams = append(comparableParams, normalizedParam)
+			}
+			if isValidator {
+				validatorParams = append(validatorParams, normalizedParam)
+			}
 			paramIndex++
 		}
 	}
-	return normalizedParams, nil
+	return normalizedParams, comparableParams, validatorParams, nil
 }
 
-func (g *Generator) getNormalizedResults(context *resolution.LocatorContext, funcType *ast.FuncType) ([]*ast.Field, error) {
+// getNormalizedResults returns the normalized results of the method along
+// with the subset of them which hold errors.
+func (g *Generator) getNormalizedResults(context *resolution.LocatorContext, funcType *ast.FuncType) ([]*ast.Field, []*ast.Field, error) {
 	normalizedResults := []*ast.Field{}
+	errorResults := []*ast.Field{}
 	resultIndex := 1
 	for result := range internal.EachFieldInFieldList(funcType.Results) {
-		count := internal.FieldTypeReuseCount(result)
-		for i := 0; i < count; i++ {
-			fieldName := fmt.Sprintf("result%d", resultIndex)
-			fieldType, err := g.Resolver.ResolveType(context, result.Type)
-			if err != nil {
-				return nil, err
-			}
-			normalizedResult := internal.CreateField(fieldName, fieldType)
+		// Error detection needs the type as seen in the interface's
+		// namespace, so it must happen before the type is resolved.
+		isError, err := g.Locator.IsNilableError(context, result.Type)
+		if err != nil {
+			return nil, nil, err
+		}
+		fieldType, err := g.Resolver.ResolveType(context, result.Type)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, name := range fieldNames(result) {
+			normalizedResult := internal.CreateField(fieldName(context, name, "result", resultIndex), fieldType)
 			normalizedResults = append(normalizedResults, normalizedResult)
+			if isError {
+				errorResults = append(errorResults, normalizedResult)
+			}
 			resultIndex++
 		}
 	}
-	return normalizedResults, nil
+	return normalizedResults, errorResults, nil
+}
+
+// declaredFields returns the fields as declared in the field list, with the
+// types of the corresponding normalized fields.
+func declaredFields(fieldList *ast.FieldList, normalized []*ast.Field) *ast.FieldList {
+	if fieldList == nil {
+		return nil
+	}
+	declared := &ast.FieldList{}
+	index := 0
+	for _, field := range fieldList.List {
+		declared.List = append(declared.List, &ast.Field{
+			Names: field.Names,
+			Type:  normalized[index].Type,
+		})
+		index += len(fieldNames(field))
+	}
+	return declared
+}
+
+// declaredType returns the declared type of the field with the specified
+// index, counting every name of the fields separately.
+func declaredType(fieldList *ast.FieldList, index int) ast.Expr {
+	for _, field := range fieldList.List {
+		names := len(fieldNames(field))
+		if index < names {
+			return field.Type
+		}
+		index -= names
+	}
+	return nil
+}
+
+// getFailureCondition builds the expression which reports whether a call
+// has failed. Unless overridden by the failure annotation, a call fails when
+// any of its error results is non-nil.
+func (g *Generator) getFailureCondition(context *resolution.LocatorContext, annotations Annotations, funcType *ast.FuncType, results, errorResults []*ast.Field) (ast.Expr, error) {
+	value, ok := annotations.Lookup(FailureAnnotation)
+	if !ok {
+		var condition ast.Expr
+		for _, result := range errorResults {
+			condition = orExpr(condition, notNilExpr(result.Names[0].String()))
+		}
+		return condition, nil
+	}
+
+	spec, err := parser.ParseExpr(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s annotation %q: %v", FailureAnnotation, value, err)
+	}
+	switch s := spec.(type) {
+	case *ast.BasicLit:
+		index, err := strconv.Atoi(s.Value)
+		if err != nil || s.Kind != token.INT || index < 1 || index > len(results) {
+			return nil, fmt.Errorf("invalid %s annotation %q: no such result", FailureAnnotation, value)
+		}
+		// The type of the result is looked up in the interface's
+		// namespace, as declared.
+		name := results[index-1].Names[0].String()
+		resultType := declaredType(funcType.Results, index-1)
+		isBool, err := g.Locator.IsBool(context, resultType)
+		if err != nil {
+			return nil, err
+		}
+		if isBool {
+			return &ast.UnaryExpr{Op: token.NOT, X: ast.NewIdent(name)}, nil
+		}
+		isNilable, err := g.Locator.IsNilable(context, resultType)
+		if err != nil {
+			return nil, err
+		}
+		if !isNilable {
+			return nil, fmt.Errorf("invalid %s annotation %q: result of type '%s' is neither bool nor nilable", FailureAnnotation, value, types.ExprString(resultType))
+		}
+		return notNilExpr(name), nil
+	case *ast.Ident, *ast.SelectorExpr:
+		predicate, err := g.Resolver.ResolveValue(context, s)
+		if err != nil {
+			return nil, err
+		}
+		args := []ast.Expr{}
+		for _, result := range results {
+			args = append(args, ast.NewIdent(result.Names[0].String()))
+		}
+		return &ast.CallExpr{Fun: predicate, Args: args}, nil
+	}
+	return nil, fmt.Errorf("invalid %s annotation %q: expected result index or predicate", FailureAnnotation, value)
+}
+
+func notNilExpr(name string) ast.Expr {
+	return &ast.BinaryExpr{
+		X:  ast.NewIdent(name),
+		Op: token.NEQ,
+		Y:  ast.NewIdent("nil"),
+	}
+}
+
+// negateExpr returns the negation of the boolean expression, pushing it down
+// comparisons and logical operators, so that it reads naturally:
+//   result1 != nil || !result2 -> result1 == nil && result2
+func negateExpr(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return negateExpr(e.X)
+	case *ast.UnaryExpr:
+		if e.Op == token.NOT {
+			return e.X
+		}
+	case *ast.BinaryExpr:
+		switch e.Op {
+		case token.EQL:
+			return &ast.BinaryExpr{X: e.X, Op: token.NEQ, Y: e.Y}
+		case token.NEQ:
+			return &ast.BinaryExpr{X: e.X, Op: token.EQL, Y: e.Y}
+		case token.LOR:
+			return &ast.BinaryExpr{X: negateOperand(e.X, token.LAND), Op: token.LAND, Y: negateOperand(e.Y, token.LAND)}
+		case token.LAND:
+			return &ast.BinaryExpr{X: negateOperand(e.X, token.LOR), Op: token.LOR, Y: negateOperand(e.Y, token.LOR)}
+		}
+		return &ast.UnaryExpr{Op: token.NOT, X: &ast.ParenExpr{X: e}}
+	}
+	return &ast.UnaryExpr{Op: token.NOT, X: expr}
+}
+
+// negateOperand negates an operand of the binary operator, parenthesizing
+// the result if it binds weaker than the operator.
+func negateOperand(expr ast.Expr, op token.Token) ast.Expr {
+	negated := negateExpr(expr)
+	if b, ok := negated.(*ast.BinaryExpr); ok && b.Op.Precedence() < op.Precedence() {
+		return &ast.ParenExpr{X: negated}
+	}
+	return negated
+}
+
+func orExpr(x, y ast.Expr) ast.Expr {
+	if x == nil {
+		return y
+	}
+	return &ast.BinaryExpr{X: x, Op: token.LOR, Y: y}
 }
@@ -0,0 +1,81 @@
+package astgen
+
+import (
+	"errors"
+	"go/ast"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/Bo0mer/gentools/pkg/resolution"
+)
+
+const testdataPath = "github.com/Bo0mer/gentools/pkg/astgen/testdata"
+
+// recordingModel records the methods added to it.
+type recordingModel struct {
+	*File
+	methods []*MethodConfig
+}
+
+func (m *recordingModel) AddMethod(method *MethodConfig) error {
+	m.methods = append(m.methods, method)
+	return nil
+}
+
+// processInterface adds the methods of the named interface in the package
+// in the location to a recording model.
+func processInterface(t *testing.T, location, name string) ([]*MethodConfig, error) {
+	t.Helper()
+	locator := resolution.NewLocator()
+	d, err := locator.FindIdentType(resolution.NewSingleLocationContext(location), ast.NewIdent(name))
+	if err != nil {
+		t.Fatalf("error finding %s: %v", name, err)
+	}
+	model := &recordingModel{File: NewFile("mws")}
+	g := Generator{
+		Model:    model,
+		Locator:  locator,
+		Resolver: resolution.NewResolver(model, locator, location+"/mws"),
+	}
+	err = g.ProcessInterface(d)
+	return model.methods, err
+}
+
+func TestFailureConditionOfResultIndex(t *testing.T) {
+	methods, err := processInterface(t, testdataPath+"/failures", "Service")
+	if err != nil {
+		t.Fatalf("ProcessInterface() error = %v", err)
+	}
+
+	want := map[string]string{
+		"Healthy": "!result1",
+		"Ready":   "!result2",
+		"Get":     "result2 != nil",
+		"Fetch":   "result1 != nil",
+		"List":    "names != nil",
+	}
+	for _, method := range methods {
+		if got := types.ExprString(method.FailureCondition); got != want[method.MethodName] {
+			t.Errorf("failure condition of %s = %s, want %s", method.MethodName, got, want[method.MethodName])
+		}
+	}
+	if len(methods) != len(want) {
+		t.Errorf("processed %d methods, want %d", len(methods), len(want))
+	}
+}
+
+func TestFailureConditionOfResultIndexNotNilable(t *testing.T) {
+	for _, name := range []string{"Counter", "Statuses"} {
+		t.Run(name, func(t *testing.T) {
+			_, err := processInterface(t, testdataPath+"/failures", name)
+			if err == nil || !strings.Contains(err.Error(), "neither bool nor nilable") {
+				t.Fatalf("ProcessInterface() error = %v, want the result to be rejected", err)
+			}
+			var declErr *resolution.DeclarationError
+			if !errors.As(err, &declErr) || !declErr.Pos.IsValid() {
+				t.Errorf("ProcessInterface() error = %v, want it reported at the annotation", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package astgen
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// Interface represents a Go interface.
+type Interface struct {
+	name    string
+	doc     *ast.CommentGroup
+	methods []*ast.Field
+}
+
+// NewInterface creates new empty interface.
+func NewInterface(name string) *Interface {
+	return &Interface{
+		name: name,
+	}
+}
+
+// SetDoc sets the doc comment of the interface. Each line of the text
+// becomes a separate comment line.
+func (i *Interface) SetDoc(text string) {
+	i.doc = &ast.CommentGroup{}
+	for _, line := range strings.Split(text, "\n") {
+		i.doc.List = append(i.doc.List, &ast.Comment{Text: strings.TrimSpace("// " + line)})
+	}
+}
+
+// AddMethod adds method with the specified signature to the interface.
+func (i *Interface) AddMethod(name string, funcType *ast.FuncType) {
+	i.methods = append(i.methods, &ast.Field{
+		Names: []*ast.Ident{
+			ast.NewIdent(name),
+		},
+		Type: funcType,
+	})
+}
+
+func (i *Interface) Build() ast.Decl {
+	return &ast.GenDecl{
+		Doc: i.doc,
+		Tok: token.TYPE,
+		Specs: []ast.Spec{
+			&ast.TypeSpec{
+				Name: ast.NewIdent(i.name),
+				Type: &ast.InterfaceType{
+					Methods: &ast.FieldList{
+						List: i.methods,
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,136 @@
+package astgen
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// MethodInvocation builds a call to the original method, as described in
+// the method configuration, on the specified receiver.
+type MethodInvocation struct {
+	receiver *ast.SelectorExpr
+	method   *MethodConfig
+}
+
+func NewMethodInvocation(method *MethodConfig) *MethodInvocation {
+	return &MethodInvocation{method: method}
+}
+
+func (m *MethodInvocation) SetReceiver(s *ast.SelectorExpr) {
+	m.receiver = s
+}
+
+// CallExpr builds the call expression:
+//   m.next.Method(arg1, arg2)
+func (m *MethodInvocation) CallExpr() *ast.CallExpr {
+	paramSelectors := []ast.Expr{}
+	ellipsisPos := token.NoPos
+	for _, param := range m.method.MethodParams {
+		paramSelectors = append(paramSelectors, ast.NewIdent(param.Names[0].String()))
+		if p, ok := param.Type.(*ast.Ellipsis); ok {
+			ellipsisPos = p.Pos()
+		}
+	}
+
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   m.receiver,
+			Sel: ast.NewIdent(m.method.MethodName),
+		},
+		Args:     paramSelectors,
+		Ellipsis: ellipsisPos,
+	}
+}
+
+// Build builds the call and defines new variables, named after the method
+// results, which hold the results:
+//   result1, result2 := m.next.Method(arg1, arg2)
+func (m *MethodInvocation) Build() ast.Stmt {
+	return m.build(token.DEFINE)
+}
+
+// BuildAssign builds the call and assigns the results to already declared
+// variables, named after the method results:
+//   result1, result2 = m.next.Method(arg1, arg2)
+func (m *MethodInvocation) BuildAssign() ast.Stmt {
+	return m.build(token.ASSIGN)
+}
+
+// BuildReturn builds the call and returns its results directly:
+//   return m.next.Method(arg1, arg2)
+func (m *MethodInvocation) BuildReturn() ast.Stmt {
+	if m.method.HasResults() {
+		return &ast.ReturnStmt{
+			Results: []ast.Expr{m.CallExpr()},
+		}
+	}
+	return &ast.ExprStmt{X: m.CallExpr()}
+}
+
+func (m *MethodInvocation) build(tok token.Token) ast.Stmt {
+	if m.method.HasResults() {
+		return &ast.AssignStmt{
+			Lhs: resultIdents(m.method),
+			Tok: tok,
+			Rhs: []ast.Expr{
+				m.CallExpr(),
+			},
+		}
+	}
+
+	return &ast.ExprStmt{X: m.CallExpr()}
+}
+
+type ReturnResults struct {
+	method *MethodConfig
+}
+
+func NewReturnResults(m *MethodConfig) *ReturnResults {
+	return &ReturnResults{m}
+}
+
+// Build builds a return statement based on the method configuration it was created with:
+//   return result1, result2
+func (r *ReturnResults) Build() ast.Stmt {
+	return &ast.ReturnStmt{
+		Results: resultIdents(r.method),
+	}
+}
+
+type DeclareResults struct {
+	method *MethodConfig
+}
+
+func NewDeclareResults(m *MethodConfig) *DeclareResults {
+	return &DeclareResults{m}
+}
+
+// Build builds a declaration of a zero value variable for each result of
+// the method:
+//   var result1 string
+//   var result2 error
+func (d *DeclareResults) Build() []ast.Stmt {
+	var stmts []ast.Stmt
+	for _, result := range d.method.MethodResults {
+		stmts = append(stmts, &ast.DeclStmt{
+			Decl: &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{
+					&ast.ValueSpec{
+						Names: []*ast.Ident{ast.NewIdent(result.Names[0].String())},
+						Type:  result.Type,
+					},
+				},
+			},
+		})
+	}
+	return stmts
+}
+
+func resultIdents(method *MethodConfig) []ast.Expr {
+	resultSelectors := []ast.Expr{}
+	for _, result := range method.MethodResults {
+		resultSelectors = append(resultSelectors, ast.NewIdent(result.Names[0].String()))
+	}
+	return resultSelectors
+}
@@ -1,6 +1,9 @@
 package astgen
 
-import "go/ast"
+import (
+	"go/ast"
+	"strings"
+)
 
 func NewMethod(name, receiverName, receiverType string) *Method {
 	return &Method{
@@ -17,6 +20,16 @@ type Method struct {
 	receiverType string
 	funcType     *ast.FuncType
 	statements   []ast.Stmt
+	doc          *ast.CommentGroup
+}
+
+// SetDoc sets the doc comment of the method. Each line of the text becomes a
+// separate comment line.
+func (m *Method) SetDoc(text string) {
+	m.doc = &ast.CommentGroup{}
+	for _, line := range strings.Split(text, "\n") {
+		m.doc.List = append(m.doc.List, &ast.Comment{Text: strings.TrimSpace("// " + line)})
+	}
 }
 
 func (m *Method) SetType(funcType *ast.FuncType) {
@@ -32,6 +45,7 @@ func (m *Method) AddStatements(stmts []ast.Stmt) {
 
 func (m *Method) Build() ast.Decl {
 	return &ast.FuncDecl{
+		Doc: m.doc,
 		Recv: &ast.FieldList{
 			List: []*ast.Field{
 				{
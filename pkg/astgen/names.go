@@ -0,0 +1,57 @@
+package astgen
+
+import (
+	"fmt"
+	"go/ast"
+	"regexp"
+
+	"github.com/Bo0mer/gentools/pkg/resolution"
+)
+
+// reservedNames are the identifiers which the generated methods refer to,
+// besides their parameters and results, and which therefore cannot name
+// them. These are the receivers of the generated methods and the predeclared
+// identifiers. The local variables of the generated methods are allocated
+// through the scope of the method instead.
+var reservedNames = map[string]bool{
+	// Receivers.
+	"m": true, "fake": true,
+	// Predeclared identifiers.
+	"any": true, "bool": true, "byte": true, "comparable": true,
+	"complex64": true, "complex128": true, "error": true, "float32": true,
+	"float64": true, "int": true, "int8": true, "int16": true, "int32": true,
+	"int64": true, "rune": true, "string": true, "uint": true, "uint8": true,
+	"uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"true": true, "false": true, "iota": true, "nil": true,
+	"append": true, "cap": true, "clear": true, "close": true, "complex": true,
+	"copy": true, "delete": true, "imag": true, "len": true, "make": true,
+	"max": true, "min": true, "new": true, "panic": true, "print": true,
+	"println": true, "real": true, "recover": true,
+}
+
+// generatedName matches the names of the import aliases and the names
+// given to the parameters and results which are not named.
+var generatedName = regexp.MustCompile(`^(alias|arg|result)[0-9]+$`)
+
+// fieldName returns the name of a parameter or result of a method, as
+// declared by the method, unless it is anonymous, blank or would collide
+// with the identifiers of the generated method, in which case the generated
+// name is returned, e.g. arg1.
+func fieldName(context *resolution.LocatorContext, name *ast.Ident, prefix string, index int) string {
+	if name != nil && name.Name != "_" && !reservedNames[name.Name] && !generatedName.MatchString(name.Name) {
+		// Type parameters are referred to by the types in the method.
+		if _, ok := context.TypeParam(name.Name); !ok {
+			return name.Name
+		}
+	}
+	return fmt.Sprintf("%s%d", prefix, index)
+}
+
+// fieldNames returns the declared names of the field, or nil for each of
+// the parameters or results sharing its type, if they are anonymous.
+func fieldNames(field *ast.Field) []*ast.Ident {
+	if len(field.Names) > 0 {
+		return field.Names
+	}
+	return []*ast.Ident{nil}
+}
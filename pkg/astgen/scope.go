@@ -0,0 +1,75 @@
+package astgen
+
+import (
+	"fmt"
+	"go/ast"
+)
+
+// Scope allocates the identifiers declared by generated code, so that they
+// collide neither with each other nor with the identifiers of the enclosing
+// scopes, e.g. the import aliases of the file or the parameters of a method.
+type Scope struct {
+	parent *Scope
+	names  map[string]bool
+}
+
+// NewScope returns a new scope nested in the parent scope, which may be nil.
+func NewScope(parent *Scope) *Scope {
+	return &Scope{
+		parent: parent,
+		names:  map[string]bool{},
+	}
+}
+
+// Reserve marks the names as taken, e.g. by parameters, which are not
+// allocated by the scope.
+func (s *Scope) Reserve(names ...string) {
+	for _, name := range names {
+		s.names[name] = true
+	}
+}
+
+// Has returns whether the name is taken in the scope or in any of the
+// enclosing scopes.
+func (s *Scope) Has(name string) bool {
+	for scope := s; scope != nil; scope = scope.parent {
+		if scope.names[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// Name allocates an identifier based on the name, which is the name itself
+// unless it is taken, in which case it is suffixed with the lowest number
+// which makes it unique, e.g. _start2. Allocating the same name twice
+// results in two distinct identifiers.
+func (s *Scope) Name(name string) string {
+	unique := name
+	for i := 2; s.Has(unique); i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	s.names[unique] = true
+	return unique
+}
+
+// MethodScope returns a new scope for the locals of a generated method, in
+// which the receiver, the parameters and the results of the method are
+// taken, along with the import aliases of the file and the identifiers in
+// the types of the parameters and results, e.g. type parameters.
+func (f *File) MethodScope(method *MethodConfig, receiverName string) *Scope {
+	scope := NewScope(f.scope)
+	scope.Reserve(receiverName)
+	for _, field := range append(append([]*ast.Field{}, method.MethodParams...), method.MethodResults...) {
+		for _, name := range field.Names {
+			scope.Reserve(name.Name)
+		}
+		ast.Inspect(field.Type, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok {
+				scope.Reserve(ident.Name)
+			}
+			return true
+		})
+	}
+	return scope
+}
@@ -0,0 +1,133 @@
+package astgen
+
+import (
+	"go/ast"
+	"reflect"
+	"testing"
+)
+
+func TestScopeName(t *testing.T) {
+	tests := []struct {
+		name     string
+		reserved []string
+		allocate []string
+		want     []string
+	}{
+		{
+			name:     "free names",
+			allocate: []string{"ctx", "err"},
+			want:     []string{"ctx", "err"},
+		},
+		{
+			name:     "reserved name",
+			reserved: []string{"err"},
+			allocate: []string{"err"},
+			want:     []string{"err2"},
+		},
+		{
+			name:     "reserved suffixed name",
+			reserved: []string{"err", "err2"},
+			allocate: []string{"err"},
+			want:     []string{"err3"},
+		},
+		{
+			name:     "allocated twice",
+			allocate: []string{"err", "err", "err"},
+			want:     []string{"err", "err2", "err3"},
+		},
+		{
+			name:     "suffixed name allocated before",
+			allocate: []string{"err2", "err", "err"},
+			want:     []string{"err2", "err", "err3"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scope := NewScope(nil)
+			scope.Reserve(test.reserved...)
+			var got []string
+			for _, name := range test.allocate {
+				got = append(got, scope.Name(name))
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("Name(%v) = %v, want %v", test.allocate, got, test.want)
+			}
+		})
+	}
+}
+
+func TestScopeNameNested(t *testing.T) {
+	parent := NewScope(nil)
+	parent.Reserve("ctx")
+	first, second := NewScope(parent), NewScope(parent)
+
+	if got := first.Name("ctx"); got != "ctx2" {
+		t.Fatalf("Name(ctx) = %q in a nested scope, want ctx2", got)
+	}
+	if got := second.Name("ctx"); got != "ctx2" {
+		t.Fatalf("Name(ctx) = %q in a sibling scope, want ctx2", got)
+	}
+	if parent.Has("ctx2") {
+		t.Fatal("name allocated in a nested scope is taken in the parent")
+	}
+}
+
+func TestMethodScopeName(t *testing.T) {
+	method := &MethodConfig{
+		MethodName: "Get",
+		MethodParams: []*ast.Field{
+			{Names: []*ast.Ident{ast.NewIdent("ctx")}, Type: &ast.SelectorExpr{X: ast.NewIdent("context"), Sel: ast.NewIdent("Context")}},
+			{Names: []*ast.Ident{ast.NewIdent("key"), ast.NewIdent("start")}, Type: ast.NewIdent("K")},
+		},
+		MethodResults: []*ast.Field{
+			{Names: []*ast.Ident{ast.NewIdent("result1")}, Type: &ast.ArrayType{Elt: ast.NewIdent("V")}},
+			{Type: ast.NewIdent("error")},
+		},
+	}
+	file := NewFile("mws")
+	file.AddImport("", "time")
+	file.AddImport("context", "context")
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		// Receiver.
+		{"m", "m2"},
+		// Parameter and result names.
+		{"ctx", "ctx2"},
+		{"start", "start2"},
+		{"key", "key2"},
+		{"result1", "result12"},
+		// Identifiers in parameter and result types.
+		{"K", "K2"},
+		{"V", "V2"},
+		{"error", "error2"},
+		// Import aliases.
+		{"alias1", "alias12"},
+		{"context", "context2"},
+		// Free names.
+		{"err", "err"},
+		{"time", "time"},
+	}
+
+	for _, test := range tests {
+		scope := file.MethodScope(method, "m")
+		if got := scope.Name(test.name); got != test.want {
+			t.Errorf("Name(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestAddImportAvoidsScopeNames(t *testing.T) {
+	file := NewFile("mws")
+	file.Scope().Reserve("alias1")
+
+	if got := file.AddImport("", "time"); got != "alias2" {
+		t.Fatalf("AddImport() = %q, want alias2", got)
+	}
+	if got := file.Scope().Name("alias2"); got != "alias22" {
+		t.Fatalf("Name(alias2) = %q after import, want alias22", got)
+	}
+}
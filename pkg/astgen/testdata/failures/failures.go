@@ -0,0 +1,43 @@
+// Package failures declares the methods whose failure annotations are
+// tested.
+package failures
+
+// Flag is a defined bool type.
+type Flag bool
+
+// Status is a defined int type.
+type Status int
+
+// Response is a response.
+type Response struct{}
+
+// Service declares methods annotated with valid result indices.
+type Service interface {
+	//gentools:failure 1
+	Healthy() bool
+
+	//gentools:failure 2
+	Ready() (int, Flag)
+
+	//gentools:failure 2
+	Get() (int, error)
+
+	//gentools:failure 1
+	Fetch() (*Response, int)
+
+	//gentools:failure 1
+	List() (names []string, count int)
+}
+
+// Counter declares a method annotated with the index of an int result.
+type Counter interface {
+	//gentools:failure 1
+	Count() (int, error)
+}
+
+// Statuses declares a method annotated with the index of a result of a
+// defined int type.
+type Statuses interface {
+	//gentools:failure 1
+	Status() Status
+}
@@ -21,22 +21,6 @@ func CreateField(name string, fieldType ast.Expr) *ast.Field {
 	}
 }
 
-func FieldsWithoutEllipsis(fields []*ast.Field) []*ast.Field {
-	result := make([]*ast.Field, len(fields))
-	for i, field := range fields {
-		result[i] = &ast.Field{
-			Names: field.Names,
-			Type:  field.Type,
-		}
-		if ellipsisType, ok := field.Type.(*ast.Ellipsis); ok {
-			result[i].Type = &ast.ArrayType{
-				Elt: ellipsisType.Elt,
-			}
-		}
-	}
-	return result
-}
-
 func ImportToDir(imp string) (string, error) {
 	pkg, err := build.Import(imp, "", build.FindOnly)
 	if err != nil {
@@ -0,0 +1,192 @@
+// Package breaker provides the circuit breakers used by the middlewares
+// generated by breakergen.
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned by the calls rejected by an open circuit breaker.
+var ErrOpen = errors.New("breaker: circuit open")
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// Closed breakers let all calls through and count their failures.
+	Closed State = iota
+	// Open breakers reject all calls until their cooldown elapses.
+	Open
+	// HalfOpen breakers let a single trial call through, which decides
+	// whether the breaker closes or opens again.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Config describes when a circuit breaker opens and for how long.
+type Config struct {
+	// FailureThreshold is the number of consecutive failures after which the
+	// breaker opens. Values less than 1 are treated as 1.
+	FailureThreshold int
+
+	// Cooldown is the time an open breaker waits before letting a trial
+	// call through.
+	Cooldown time.Duration
+}
+
+// Breaker is a circuit breaker. It is safe for concurrent use.
+type Breaker struct {
+	config Config
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// New returns a closed circuit breaker with the specified configuration.
+func New(config Config) *Breaker {
+	return &Breaker{config: config}
+}
+
+// Allow reports whether a call may proceed. It returns ErrOpen if the call
+// is rejected. Every allowed call must be followed by a call to Done, or to
+// Recover if it panics.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open && time.Since(b.openedAt) >= b.config.Cooldown {
+		b.state = HalfOpen
+	}
+	switch b.state {
+	case Open:
+		return ErrOpen
+	case HalfOpen:
+		if b.trial {
+			return ErrOpen
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+// Done records the outcome of an allowed call.
+func (b *Breaker) Done(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == HalfOpen {
+		b.trial = false
+		if failed {
+			b.open()
+		} else {
+			b.state = Closed
+			b.failures = 0
+		}
+		return
+	}
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == Closed && b.failures >= b.config.FailureThreshold {
+		b.open()
+	}
+}
+
+// Recover records the outcome of an allowed call which panicked as failed,
+// and resumes the panic. It must be deferred right after the call is
+// allowed, so that a panicking trial call does not leave the breaker
+// half-open, rejecting all calls, for good:
+//   if err := b.Allow(); err != nil {
+//     return err
+//   }
+//   defer b.Recover()
+func (b *Breaker) Recover() {
+	if r := recover(); r != nil {
+		b.Done(true)
+		panic(r)
+	}
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open && time.Since(b.openedAt) >= b.config.Cooldown {
+		return HalfOpen
+	}
+	return b.state
+}
+
+func (b *Breaker) open() {
+	b.state = Open
+	b.openedAt = time.Now()
+	b.failures = 0
+}
+
+// Breakers provides the circuit breakers guarding the methods of an
+// interface.
+type Breakers interface {
+	// Breaker returns the breaker guarding the specified method.
+	Breaker(method string) *Breaker
+}
+
+type shared struct {
+	breaker *Breaker
+}
+
+// Shared returns Breakers which guard all methods with a single breaker, so
+// that failures of any method open the circuit for all of them.
+func Shared(config Config) Breakers {
+	return &shared{breaker: New(config)}
+}
+
+func (s *shared) Breaker(string) *Breaker {
+	return s.breaker
+}
+
+type perMethod struct {
+	config Config
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// PerMethod returns Breakers which guard each method with its own breaker.
+func PerMethod(config Config) Breakers {
+	return &perMethod{
+		config:   config,
+		breakers: make(map[string]*Breaker),
+	}
+}
+
+func (p *perMethod) Breaker(method string) *Breaker {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	b, ok := p.breakers[method]
+	if !ok {
+		b = New(p.config)
+		p.breakers[method] = b
+	}
+	return b
+}
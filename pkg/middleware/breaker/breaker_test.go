@@ -0,0 +1,119 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+// elapseCooldown makes the cooldown of the open breaker elapse.
+func elapseCooldown(b *Breaker) {
+	b.mu.Lock()
+	b.openedAt = time.Now().Add(-b.config.Cooldown)
+	b.mu.Unlock()
+}
+
+func expectState(t *testing.T, b *Breaker, want State) {
+	t.Helper()
+	if got := b.State(); got != want {
+		t.Fatalf("state = %v, want %v", got, want)
+	}
+}
+
+func TestBreakerStateMachine(t *testing.T) {
+	b := New(Config{FailureThreshold: 2, Cooldown: time.Hour})
+	expectState(t, b, Closed)
+
+	// Successes reset the count of consecutive failures.
+	for _, failed := range []bool{true, false, true} {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("closed breaker rejected call: %v", err)
+		}
+		b.Done(failed)
+	}
+	expectState(t, b, Closed)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("closed breaker rejected call: %v", err)
+	}
+	b.Done(true)
+	expectState(t, b, Open)
+	if err := b.Allow(); err != ErrOpen {
+		t.Fatalf("open breaker allowed call, err = %v", err)
+	}
+
+	elapseCooldown(b)
+	expectState(t, b, HalfOpen)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("half-open breaker rejected trial call: %v", err)
+	}
+	if err := b.Allow(); err != ErrOpen {
+		t.Fatalf("half-open breaker allowed second call during trial, err = %v", err)
+	}
+	b.Done(false)
+	expectState(t, b, Closed)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("closed breaker rejected call: %v", err)
+	}
+	b.Done(false)
+}
+
+func TestBreakerFailedTrialReopens(t *testing.T) {
+	b := New(Config{FailureThreshold: 1, Cooldown: time.Hour})
+	if err := b.Allow(); err != nil {
+		t.Fatalf("closed breaker rejected call: %v", err)
+	}
+	b.Done(true)
+	elapseCooldown(b)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("half-open breaker rejected trial call: %v", err)
+	}
+	b.Done(true)
+	expectState(t, b, Open)
+}
+
+func TestBreakerPanickingTrial(t *testing.T) {
+	b := New(Config{FailureThreshold: 1, Cooldown: time.Hour})
+	if err := b.Allow(); err != nil {
+		t.Fatalf("closed breaker rejected call: %v", err)
+	}
+	b.Done(true)
+	elapseCooldown(b)
+
+	// The call is guarded the way the generated middlewares guard it.
+	call := func() {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("half-open breaker rejected trial call: %v", err)
+		}
+		defer b.Recover()
+		panic("boom")
+	}
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Fatalf("recovered %v, want the panic of the call", r)
+			}
+		}()
+		call()
+	}()
+
+	expectState(t, b, Open)
+	elapseCooldown(b)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("breaker rejected trial call after panicking trial: %v", err)
+	}
+	b.Done(false)
+	expectState(t, b, Closed)
+}
+
+func TestBreakerRecoverWithoutPanic(t *testing.T) {
+	b := New(Config{FailureThreshold: 1, Cooldown: time.Hour})
+	func() {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("closed breaker rejected call: %v", err)
+		}
+		defer b.Recover()
+		b.Done(false)
+	}()
+	expectState(t, b, Closed)
+}
@@ -0,0 +1,162 @@
+// Package cache provides the caches used by the middlewares generated by
+// cachegen.
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Key identifies the results of a call.
+type Key struct {
+	// Method is the name of the called method.
+	Method string
+
+	// Args holds the arguments of the call that make up the key. It must
+	// be comparable.
+	Args interface{}
+}
+
+// Cache stores the results of calls.
+type Cache interface {
+	// Get returns the value stored under the specified key, if it has not
+	// expired.
+	Get(key Key) (value interface{}, ok bool)
+
+	// Set stores the value under the specified key for the specified time.
+	// Values with non-positive TTL never expire.
+	Set(key Key, value interface{}, ttl time.Duration)
+
+	// Invalidate removes the values of all calls to the specified method.
+	Invalidate(method string)
+}
+
+type entry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// Memory is a Cache which stores the values in memory. Expired values are
+// removed lazily. It is safe for concurrent use.
+type Memory struct {
+	mu      sync.Mutex
+	entries map[Key]entry
+}
+
+// NewMemory returns an empty in-memory cache.
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[Key]entry)}
+}
+
+func (m *Memory) Get(key Key) (interface{}, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !e.expiresAt.IsZero() && !time.Now().Before(e.expiresAt) {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (m *Memory) Set(key Key, value interface{}, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := entry{value: value}
+	if ttl > 0 {
+		e.expiresAt = time.Now().Add(ttl)
+	}
+	m.entries[key] = e
+}
+
+func (m *Memory) Invalidate(method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key := range m.entries {
+		if key.Method == method {
+			delete(m.entries, key)
+		}
+	}
+}
+
+type call struct {
+	method string
+	done   chan struct{}
+	value  interface{}
+	shared bool
+	stale  bool
+}
+
+// Group de-duplicates concurrent calls with the same key, so that only the
+// first one is executed and the rest share its value, and keeps the values
+// loaded before an invalidation out of the cache. The zero value is ready
+// for use.
+type Group struct {
+	mu    sync.Mutex
+	calls map[Key]*call
+}
+
+// Do returns the value of fn, which is stored in the cache under the key for
+// the ttl if fn reports it as cacheable, unless the values of the method of
+// the key are invalidated while fn is in flight, as it may be stale.
+//
+// Concurrent calls with the same key wait for the first one and share its
+// value, as long as it is stored. Otherwise, e.g. if the first call failed
+// because its context was canceled, or if it panicked, they call their own
+// fn instead.
+func (g *Group) Do(cache Cache, key Key, ttl time.Duration, fn func() (value interface{}, cacheable bool)) interface{} {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[Key]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-c.done
+		if c.shared {
+			return c.value
+		}
+		return g.Do(cache, key, ttl, fn)
+	}
+	c := &call{method: key.Method, done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	// The call is removed even if fn panics, so that the waiting calls
+	// are not blocked for good.
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+	value, cacheable := fn()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c.value = value
+	if cacheable && !c.stale {
+		cache.Set(key, value, ttl)
+		c.shared = true
+	}
+	return value
+}
+
+// Invalidate removes the values of all calls to the specified method from
+// the cache, and keeps the values of such calls in flight out of it.
+func (g *Group) Invalidate(cache Cache, method string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, c := range g.calls {
+		if c.method == method {
+			c.stale = true
+		}
+	}
+	cache.Invalidate(method)
+}
@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitForCall blocks until a call with the key is in flight.
+func waitForCall(g *Group, key Key) {
+	for {
+		g.mu.Lock()
+		_, ok := g.calls[key]
+		g.mu.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestGroupSharesCachedValue(t *testing.T) {
+	var g Group
+	cache := NewMemory()
+	key := Key{Method: "Get", Args: [1]interface{}{1}}
+
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		g.Do(cache, key, 0, func() (interface{}, bool) {
+			<-release
+			return "first", true
+		})
+	}()
+	waitForCall(&g, key)
+
+	var value interface{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		value = g.Do(cache, key, 0, func() (interface{}, bool) {
+			t.Error("waiting call was not de-duplicated")
+			return "second", true
+		})
+	}()
+	// Give the second call the time to wait for the first one.
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if value != "first" {
+		t.Fatalf("value = %v, want the value of the first call", value)
+	}
+	if cached, ok := cache.Get(key); !ok || cached != "first" {
+		t.Fatalf("cached value = %v, %v, want first", cached, ok)
+	}
+}
+
+func TestGroupWaiterCallsAfterFailure(t *testing.T) {
+	var g Group
+	cache := NewMemory()
+	key := Key{Method: "Get"}
+
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		g.Do(cache, key, 0, func() (interface{}, bool) {
+			<-release
+			return "canceled", false
+		})
+	}()
+	waitForCall(&g, key)
+
+	values := make(chan interface{})
+	go func() {
+		values <- g.Do(cache, key, 0, func() (interface{}, bool) {
+			return "own", true
+		})
+	}()
+	close(release)
+	<-done
+
+	if value := <-values; value != "own" {
+		t.Fatalf("value = %v, want the value of the own call", value)
+	}
+}
+
+func TestGroupWaiterCallsAfterPanic(t *testing.T) {
+	var g Group
+	cache := NewMemory()
+	key := Key{Method: "Get"}
+
+	release := make(chan struct{})
+	recovered := make(chan interface{})
+	go func() {
+		defer func() {
+			recovered <- recover()
+		}()
+		g.Do(cache, key, 0, func() (interface{}, bool) {
+			<-release
+			panic("boom")
+		})
+	}()
+	waitForCall(&g, key)
+
+	values := make(chan interface{})
+	go func() {
+		values <- g.Do(cache, key, 0, func() (interface{}, bool) {
+			return "own", true
+		})
+	}()
+	close(release)
+
+	if r := <-recovered; r != "boom" {
+		t.Fatalf("recovered %v, want the panic of the call", r)
+	}
+	if value := <-values; value != "own" {
+		t.Fatalf("value = %v, want the value of the own call", value)
+	}
+}
+
+func TestGroupInvalidateDropsCallInFlight(t *testing.T) {
+	var g Group
+	cache := NewMemory()
+	key := Key{Method: "Get"}
+	other := Key{Method: "List"}
+
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, k := range []Key{key, other} {
+		k := k
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.Do(cache, k, 0, func() (interface{}, bool) {
+				<-release
+				return "stale", true
+			})
+		}()
+		waitForCall(&g, k)
+	}
+
+	g.Invalidate(cache, "Get")
+	close(release)
+	wg.Wait()
+
+	if value, ok := cache.Get(key); ok {
+		t.Fatalf("value loaded before invalidation was cached: %v", value)
+	}
+	if _, ok := cache.Get(other); !ok {
+		t.Fatal("value of another method was not cached")
+	}
+
+	g.Do(cache, key, 0, func() (interface{}, bool) {
+		return "fresh", true
+	})
+	if value, ok := cache.Get(key); !ok || value != "fresh" {
+		t.Fatalf("cached value = %v, %v, want fresh", value, ok)
+	}
+}
@@ -0,0 +1,61 @@
+// Package intercept provides the interceptors used by the middlewares
+// generated by interceptgen.
+package intercept
+
+import "context"
+
+// Interceptor is invoked around every call to an intercepted interface.
+//
+// Before is invoked with the name of the called method and its arguments,
+// except for the context.Context first argument, if any. The context it
+// returns is passed to the wrapped implementation, if the method takes one,
+// and to After. Methods without a context are intercepted with
+// context.Background.
+//
+// After is invoked with the results of the call and its error, which is the
+// first non-nil error result, if any.
+type Interceptor interface {
+	Before(ctx context.Context, method string, args []interface{}) context.Context
+	After(ctx context.Context, method string, results []interface{}, err error)
+}
+
+// Funcs is an Interceptor that calls the non-nil funcs it holds.
+type Funcs struct {
+	BeforeFunc func(ctx context.Context, method string, args []interface{}) context.Context
+	AfterFunc  func(ctx context.Context, method string, results []interface{}, err error)
+}
+
+func (f Funcs) Before(ctx context.Context, method string, args []interface{}) context.Context {
+	if f.BeforeFunc == nil {
+		return ctx
+	}
+	return f.BeforeFunc(ctx, method, args)
+}
+
+func (f Funcs) After(ctx context.Context, method string, results []interface{}, err error) {
+	if f.AfterFunc != nil {
+		f.AfterFunc(ctx, method, results, err)
+	}
+}
+
+// Chain returns an Interceptor that invokes the specified interceptors in
+// order before a call and in reverse order after it, as if the middlewares
+// of the interceptors were nested.
+func Chain(interceptors ...Interceptor) Interceptor {
+	return chain(interceptors)
+}
+
+type chain []Interceptor
+
+func (c chain) Before(ctx context.Context, method string, args []interface{}) context.Context {
+	for _, i := range c {
+		ctx = i.Before(ctx, method, args)
+	}
+	return ctx
+}
+
+func (c chain) After(ctx context.Context, method string, results []interface{}, err error) {
+	for i := len(c) - 1; i >= 0; i-- {
+		c[i].After(ctx, method, results, err)
+	}
+}
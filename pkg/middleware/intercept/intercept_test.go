@@ -0,0 +1,93 @@
+package intercept
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type key struct{}
+
+// recorder returns an interceptor which records its invocations in calls
+// and adds its name to the context it passes on.
+func recorder(name string, calls *[]string) Interceptor {
+	return Funcs{
+		BeforeFunc: func(ctx context.Context, method string, args []interface{}) context.Context {
+			*calls = append(*calls, name+".Before "+method)
+			path, _ := ctx.Value(key{}).(string)
+			return context.WithValue(ctx, key{}, path+"/"+name)
+		},
+		AfterFunc: func(ctx context.Context, method string, results []interface{}, err error) {
+			path, _ := ctx.Value(key{}).(string)
+			*calls = append(*calls, name+".After "+method+" "+path)
+		},
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	c := Chain(recorder("a", &calls), recorder("b", &calls), recorder("c", &calls))
+
+	ctx := c.Before(context.Background(), "Get", nil)
+	calls = append(calls, "call")
+	c.After(ctx, "Get", nil, nil)
+
+	want := []string{
+		"a.Before Get",
+		"b.Before Get",
+		"c.Before Get",
+		"call",
+		"c.After Get /a/b/c",
+		"b.After Get /a/b/c",
+		"a.After Get /a/b/c",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls = %q, want %q", calls, want)
+	}
+}
+
+func TestChainPassesArgsAndResults(t *testing.T) {
+	args := []interface{}{1, "two"}
+	results := []interface{}{3}
+	callErr := errors.New("failed")
+
+	var gotArgs, gotResults []interface{}
+	var gotErr error
+	c := Chain(Funcs{
+		BeforeFunc: func(ctx context.Context, method string, a []interface{}) context.Context {
+			gotArgs = a
+			return ctx
+		},
+		AfterFunc: func(ctx context.Context, method string, r []interface{}, err error) {
+			gotResults, gotErr = r, err
+		},
+	})
+	c.After(c.Before(context.Background(), "Get", args), "Get", results, callErr)
+
+	if !reflect.DeepEqual(gotArgs, args) || !reflect.DeepEqual(gotResults, results) || gotErr != callErr {
+		t.Fatalf("intercepted %v, %v, %v, want %v, %v, %v", gotArgs, gotResults, gotErr, args, results, callErr)
+	}
+}
+
+func TestChainSkipsMissingFuncs(t *testing.T) {
+	var calls []string
+	c := Chain(Funcs{}, recorder("a", &calls), Funcs{})
+
+	ctx := c.Before(context.Background(), "Get", nil)
+	c.After(ctx, "Get", nil, nil)
+
+	want := []string{"a.Before Get", "a.After Get /a"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls = %q, want %q", calls, want)
+	}
+}
+
+func TestEmptyChain(t *testing.T) {
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	c := Chain()
+	if got := c.Before(ctx, "Get", nil); got != ctx {
+		t.Fatal("empty chain replaced the context")
+	}
+	c.After(ctx, "Get", nil, nil)
+}
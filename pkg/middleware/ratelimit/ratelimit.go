@@ -0,0 +1,189 @@
+// Package ratelimit provides the limiters used by the middlewares generated
+// by ratelimitgen.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Limiter controls how frequently calls are allowed to happen. It is
+// satisfied by *rate.Limiter from golang.org/x/time/rate.
+type Limiter interface {
+	// Wait blocks until a call is allowed or the context is done, in which
+	// case it returns an error.
+	Wait(ctx context.Context) error
+
+	// Allow reports whether a call may happen now.
+	Allow() bool
+}
+
+// ErrDeadlineExceeded is returned by TokenBucket.Wait when the wait for a
+// token would outlast the deadline of the context.
+var ErrDeadlineExceeded = errors.New("ratelimit: wait would exceed context deadline")
+
+// LimitExceededError is returned by the calls rejected because their
+// method's limit was exceeded.
+type LimitExceededError struct {
+	// Method is the name of the rejected method.
+	Method string
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("ratelimit: limit of %s exceeded", e.Method)
+}
+
+// Option configures the limiters of a rate limiting middleware.
+type Option func(*Limiters)
+
+// WithMethodLimiter limits calls to the specified method with the specified
+// limiter instead of the default one.
+func WithMethodLimiter(method string, limiter Limiter) Option {
+	return func(l *Limiters) {
+		l.methods[method] = limiter
+	}
+}
+
+// WithMethodLimit limits calls to the specified method to the specified
+// number of calls per second, with bursts of at most burst calls.
+func WithMethodLimit(method string, perSecond float64, burst int) Option {
+	return WithMethodLimiter(method, NewTokenBucket(perSecond, burst))
+}
+
+// Limiters holds the limiters of the methods of an interface.
+type Limiters struct {
+	def     Limiter
+	methods map[string]Limiter
+}
+
+// NewLimiters returns Limiters which limit all methods with the specified
+// default limiter, unless overridden by the options. A nil default limiter
+// does not limit the calls at all.
+func NewLimiters(def Limiter, opts ...Option) *Limiters {
+	if def == nil {
+		def = unlimited{}
+	}
+	l := &Limiters{
+		def:     def,
+		methods: make(map[string]Limiter),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Limiter returns the limiter of the specified method.
+func (l *Limiters) Limiter(method string) Limiter {
+	if limiter, ok := l.methods[method]; ok && limiter != nil {
+		return limiter
+	}
+	return l.def
+}
+
+type unlimited struct{}
+
+func (unlimited) Wait(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (unlimited) Allow() bool {
+	return true
+}
+
+// TokenBucket is a Limiter which allows calls at a steady rate, with
+// occasional bursts. It is safe for concurrent use.
+type TokenBucket struct {
+	perSecond float64
+	burst     int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a full bucket which refills with perSecond tokens
+// per second and holds at most burst tokens. Every call takes one token.
+func NewTokenBucket(perSecond float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		perSecond: perSecond,
+		burst:     burst,
+		tokens:    float64(burst),
+		last:      time.Now(),
+	}
+}
+
+// Allow takes a token if one is available.
+func (b *TokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Wait takes a token, waiting for one to become available if necessary. It
+// returns the error of the context without taking a token if it is done
+// first, or ErrDeadlineExceeded if its deadline would be exceeded.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		b.mu.Unlock()
+		return nil
+	}
+	if b.perSecond <= 0 {
+		b.mu.Unlock()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	// Reserve the token ahead of time, so that concurrent waiters are
+	// served in order.
+	delay := time.Duration((1 - b.tokens) / b.perSecond * float64(time.Second))
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(delay)) {
+		b.mu.Unlock()
+		return ErrDeadlineExceeded
+	}
+	b.tokens--
+	b.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.refill(time.Now())
+		b.tokens++
+		if b.tokens > float64(b.burst) {
+			b.tokens = float64(b.burst)
+		}
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (b *TokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last)
+	b.last = now
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens += elapsed.Seconds() * b.perSecond
+	if b.tokens > float64(b.burst) {
+		b.tokens = float64(b.burst)
+	}
+}
@@ -0,0 +1,180 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokens returns the number of tokens in the bucket.
+func tokens(b *TokenBucket) float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
+
+func TestTokenBucketAllow(t *testing.T) {
+	tests := []struct {
+		name  string
+		burst int
+		calls int
+		want  int
+	}{
+		{"burst", 3, 5, 3},
+		{"single", 1, 3, 1},
+		{"non-positive burst", 0, 3, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The bucket is never refilled.
+			b := NewTokenBucket(0, test.burst)
+			allowed := 0
+			for i := 0; i < test.calls; i++ {
+				if b.Allow() {
+					allowed++
+				}
+			}
+			if allowed != test.want {
+				t.Fatalf("allowed %d calls, want %d", allowed, test.want)
+			}
+		})
+	}
+}
+
+func TestTokenBucketRefills(t *testing.T) {
+	b := NewTokenBucket(1000, 1)
+	if !b.Allow() {
+		t.Fatal("full bucket rejected call")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("refilled bucket rejected call")
+	}
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	tests := []struct {
+		name      string
+		perSecond float64
+		timeout   time.Duration
+		want      error
+	}{
+		{"token refilled in time", 100, time.Second, nil},
+		{"deadline exceeded", 1, 10 * time.Millisecond, ErrDeadlineExceeded},
+		{"never refilled", 0, 10 * time.Millisecond, context.DeadlineExceeded},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := NewTokenBucket(test.perSecond, 1)
+			b.Allow()
+			before := tokens(b)
+
+			ctx, cancel := context.WithTimeout(context.Background(), test.timeout)
+			defer cancel()
+			if err := b.Wait(ctx); err != test.want {
+				t.Fatalf("Wait() error = %v, want %v", err, test.want)
+			}
+			// Failed waits take no token.
+			if test.want != nil && tokens(b) < before {
+				t.Fatalf("tokens = %v after failed wait, want at least %v", tokens(b), before)
+			}
+		})
+	}
+}
+
+func TestTokenBucketWaitRefundsCanceledReservation(t *testing.T) {
+	b := NewTokenBucket(1, 1)
+	b.Allow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		errs <- b.Wait(ctx)
+	}()
+	// Wait for the token to be reserved.
+	for tokens(b) > -0.5 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	if err := <-errs; err != context.Canceled {
+		t.Fatalf("Wait() error = %v, want %v", err, context.Canceled)
+	}
+	if got := tokens(b); got < -0.5 {
+		t.Fatalf("tokens = %v after canceled wait, want the reservation refunded", got)
+	}
+}
+
+func TestTokenBucketWaitConcurrent(t *testing.T) {
+	const waiters = 5
+	b := NewTokenBucket(200, 1)
+	b.Allow()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	errs := make(chan error, waiters)
+	for i := 0; i < waiters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- b.Wait(context.Background())
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	// Every waiter reserves its own token, which are refilled one every
+	// 5ms.
+	if elapsed := time.Since(start); elapsed < waiters*5*time.Millisecond*9/10 {
+		t.Fatalf("%d waiters were served in %v, faster than the rate allows", waiters, elapsed)
+	}
+}
+
+func TestLimiters(t *testing.T) {
+	def := NewTokenBucket(0, 1)
+	get := NewTokenBucket(0, 2)
+	l := NewLimiters(def, WithMethodLimiter("Get", get), WithMethodLimiter("List", nil))
+
+	tests := []struct {
+		method string
+		want   Limiter
+	}{
+		{"Get", get},
+		{"List", def},
+		{"Put", def},
+	}
+	for _, test := range tests {
+		if got := l.Limiter(test.method); got != test.want {
+			t.Errorf("Limiter(%q) = %p, want %p", test.method, got, test.want)
+		}
+	}
+}
+
+func TestLimitersWithoutDefault(t *testing.T) {
+	l := NewLimiters(nil, WithMethodLimit("Get", 0, 1))
+
+	limiter := l.Limiter("Put")
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow() {
+			t.Fatal("unlimited limiter rejected call")
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx); err != context.Canceled {
+		t.Fatalf("Wait() error = %v, want %v", err, context.Canceled)
+	}
+
+	get := l.Limiter("Get")
+	if !get.Allow() || get.Allow() {
+		t.Fatal("method limit was not applied")
+	}
+}
@@ -0,0 +1,93 @@
+// Package retry provides the retry policies used by the middlewares generated
+// by retrygen.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ErrFailed is reported to the Retryable predicate of a policy when a call
+// has failed, according to its failure annotation, without returning an
+// error.
+var ErrFailed = errors.New("retry: operation failed")
+
+// Policy describes how failed calls are retried.
+type Policy struct {
+	// MaxAttempts is the maximum number of attempts, including the first
+	// one. Values less than 1 are treated as 1.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between attempts. Zero means no cap.
+	MaxBackoff time.Duration
+
+	// Multiplier is the factor by which the delay grows after each retry.
+	// Values less than 1 are treated as 1, i.e. constant backoff.
+	Multiplier float64
+
+	// Jitter is the fraction, between 0 and 1, by which each delay is
+	// randomly reduced in order to spread retries of concurrent callers.
+	Jitter float64
+
+	// Retryable reports whether a call that failed with err should be
+	// retried. If nil, all failures are retried.
+	Retryable func(err error) bool
+}
+
+// DefaultPolicy returns a policy which makes up to 3 attempts with exponential
+// backoff starting at 100ms.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// Do calls fn until it returns nil, returns an error that is not retryable or
+// the attempts are exhausted. The first attempt is always made. Waiting
+// between attempts is aborted when ctx is done, in which case the context
+// error is returned. Otherwise, the error of the last attempt is returned.
+func (p Policy) Do(ctx context.Context, fn func() error) error {
+	backoff := p.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts {
+			return err
+		}
+		if p.Retryable != nil && !p.Retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(p.jitter(backoff))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff = p.next(backoff)
+	}
+}
+
+func (p Policy) next(backoff time.Duration) time.Duration {
+	multiplier := math.Max(p.Multiplier, 1)
+	next := time.Duration(float64(backoff) * multiplier)
+	if p.MaxBackoff > 0 && next > p.MaxBackoff {
+		next = p.MaxBackoff
+	}
+	return next
+}
+
+func (p Policy) jitter(backoff time.Duration) time.Duration {
+	jitter := math.Min(math.Max(p.Jitter, 0), 1)
+	return backoff - time.Duration(jitter*rand.Float64()*float64(backoff))
+}
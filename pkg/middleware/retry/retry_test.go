@@ -0,0 +1,144 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPolicyDoAttempts(t *testing.T) {
+	errRetryable := errors.New("retryable")
+	tests := []struct {
+		name        string
+		maxAttempts int
+		retryable   func(err error) bool
+		succeedAt   int
+		want        int
+	}{
+		{"attempts exhausted", 3, nil, 0, 3},
+		{"single attempt", 1, nil, 0, 1},
+		{"no attempts", 0, nil, 0, 1},
+		{"succeeds", 3, nil, 2, 2},
+		{"not retryable", 3, func(err error) bool { return err == errRetryable }, 0, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := Policy{MaxAttempts: test.maxAttempts, Retryable: test.retryable}
+			attempts := 0
+			err := p.Do(context.Background(), func() error {
+				attempts++
+				if attempts == test.succeedAt {
+					return nil
+				}
+				return fmt.Errorf("attempt %d", attempts)
+			})
+			if attempts != test.want {
+				t.Fatalf("made %d attempts, want %d", attempts, test.want)
+			}
+			if test.succeedAt > 0 {
+				if err != nil {
+					t.Fatalf("Do() error = %v, want nil", err)
+				}
+				return
+			}
+			// The error of the last attempt is returned.
+			if want := fmt.Sprintf("attempt %d", attempts); err == nil || err.Error() != want {
+				t.Fatalf("Do() error = %v, want %s", err, want)
+			}
+		})
+	}
+}
+
+func TestPolicyDoWaitsBetweenAttempts(t *testing.T) {
+	p := Policy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond, Multiplier: 2}
+	var times []time.Time
+	p.Do(context.Background(), func() error {
+		times = append(times, time.Now())
+		return errors.New("failed")
+	})
+
+	for i, want := range []time.Duration{10 * time.Millisecond, 20 * time.Millisecond} {
+		if got := times[i+1].Sub(times[i]); got < want {
+			t.Errorf("backoff %d = %v, want at least %v", i+1, got, want)
+		}
+	}
+}
+
+func TestPolicyDoCanceledDuringBackoff(t *testing.T) {
+	p := Policy{MaxAttempts: 3, InitialBackoff: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	err := p.Do(ctx, func() error {
+		attempts++
+		cancel()
+		return errors.New("failed")
+	})
+	if err != context.Canceled {
+		t.Fatalf("Do() error = %v, want %v", err, context.Canceled)
+	}
+	if attempts != 1 {
+		t.Fatalf("made %d attempts, want 1", attempts)
+	}
+}
+
+func TestPolicyBackoff(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		want   []time.Duration
+	}{
+		{
+			"exponential",
+			Policy{InitialBackoff: time.Second, Multiplier: 2},
+			[]time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+		{
+			"capped",
+			Policy{InitialBackoff: time.Second, Multiplier: 3, MaxBackoff: 5 * time.Second},
+			[]time.Duration{time.Second, 3 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		{
+			"constant",
+			Policy{InitialBackoff: time.Second, Multiplier: 0.5},
+			[]time.Duration{time.Second, time.Second, time.Second},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backoff := test.policy.InitialBackoff
+			for i, want := range test.want {
+				if backoff != want {
+					t.Fatalf("backoff %d = %v, want %v", i+1, backoff, want)
+				}
+				backoff = test.policy.next(backoff)
+			}
+		})
+	}
+}
+
+func TestPolicyJitter(t *testing.T) {
+	tests := []struct {
+		jitter float64
+		min    time.Duration
+	}{
+		{0, time.Second},
+		{-1, time.Second},
+		{0.25, 750 * time.Millisecond},
+		{2, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.jitter), func(t *testing.T) {
+			p := Policy{Jitter: test.jitter}
+			for i := 0; i < 100; i++ {
+				if got := p.jitter(time.Second); got < test.min || got > time.Second {
+					t.Fatalf("jitter(1s) = %v, want between %v and 1s", got, test.min)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Package timeout provides the timeouts used by the middlewares generated by
+// timeoutgen.
+package timeout
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TimeoutError is returned by the calls to methods without a context, which
+// did not complete within their timeout.
+type TimeoutError struct {
+	// Method is the name of the timed out method.
+	Method string
+
+	// Duration is the timeout that was exceeded.
+	Duration time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timeout: %s did not complete within %v", e.Method, e.Duration)
+}
+
+// Timeout reports that the error is a timeout, similarly to net.Error.
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+// Option configures the timeouts of a timeout middleware.
+type Option func(*Timeouts)
+
+// WithMethodTimeout sets the timeout of the specified method instead of the
+// default one.
+func WithMethodTimeout(method string, timeout time.Duration) Option {
+	return func(t *Timeouts) {
+		t.methods[method] = timeout
+	}
+}
+
+// WithMethodTimeouts sets the timeouts of the methods in the map, keyed by
+// method name, instead of the default one.
+func WithMethodTimeouts(timeouts map[string]time.Duration) Option {
+	return func(t *Timeouts) {
+		for method, timeout := range timeouts {
+			t.methods[method] = timeout
+		}
+	}
+}
+
+// Timeouts holds the timeouts of the methods of an interface.
+type Timeouts struct {
+	def     time.Duration
+	methods map[string]time.Duration
+}
+
+// NewTimeouts returns Timeouts which apply the specified default timeout to
+// all methods, unless overridden by the options. Non-positive timeouts
+// disable the timeout.
+func NewTimeouts(def time.Duration, opts ...Option) *Timeouts {
+	t := &Timeouts{
+		def:     def,
+		methods: make(map[string]time.Duration),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Timeout returns the timeout of the specified method.
+func (t *Timeouts) Timeout(method string) time.Duration {
+	if timeout, ok := t.methods[method]; ok {
+		return timeout
+	}
+	return t.def
+}
+
+// Context returns a copy of the parent context which is cancelled after the
+// specified timeout, along with a function that releases its resources. If
+// the timeout is not positive, the parent context is returned as is.
+func Context(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return parent, func() {}
+	}
+	return context.WithTimeout(parent, timeout)
+}
@@ -0,0 +1,97 @@
+package timeout
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeouts(t *testing.T) {
+	timeouts := NewTimeouts(time.Second,
+		WithMethodTimeout("Get", 2*time.Second),
+		WithMethodTimeouts(map[string]time.Duration{"List": 3 * time.Second, "Put": 0}),
+	)
+
+	tests := []struct {
+		method string
+		want   time.Duration
+	}{
+		{"Get", 2 * time.Second},
+		{"List", 3 * time.Second},
+		{"Put", 0},
+		{"Delete", time.Second},
+	}
+	for _, test := range tests {
+		if got := timeouts.Timeout(test.method); got != test.want {
+			t.Errorf("Timeout(%q) = %v, want %v", test.method, got, test.want)
+		}
+	}
+}
+
+func TestContextDeadline(t *testing.T) {
+	ctx, cancel := Context(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("context has no deadline")
+	}
+	if remaining := time.Until(deadline); remaining > 10*time.Millisecond {
+		t.Fatalf("deadline in %v, want at most 10ms", remaining)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context was not done after its timeout")
+	}
+	if err := ctx.Err(); err != context.DeadlineExceeded {
+		t.Fatalf("context error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestContextKeepsEarlierParentDeadline(t *testing.T) {
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelParent()
+	ctx, cancel := Context(parent, time.Hour)
+	defer cancel()
+
+	parentDeadline, _ := parent.Deadline()
+	if deadline, _ := ctx.Deadline(); !deadline.Equal(parentDeadline) {
+		t.Fatalf("deadline = %v, want the deadline of the parent %v", deadline, parentDeadline)
+	}
+}
+
+func TestContextPassThrough(t *testing.T) {
+	for _, timeout := range []time.Duration{0, -time.Second} {
+		t.Run(timeout.String(), func(t *testing.T) {
+			type key struct{}
+			parent, cancelParent := context.WithCancel(context.WithValue(context.Background(), key{}, "value"))
+			ctx, cancel := Context(parent, timeout)
+
+			if ctx != parent {
+				t.Fatal("context without timeout is not the parent")
+			}
+			// Releasing the context leaves the parent intact.
+			cancel()
+			if err := parent.Err(); err != nil {
+				t.Fatalf("parent error = %v after release, want nil", err)
+			}
+			cancelParent()
+			if err := ctx.Err(); err != context.Canceled {
+				t.Fatalf("context error = %v, want %v", err, context.Canceled)
+			}
+		})
+	}
+}
+
+func TestTimeoutError(t *testing.T) {
+	var err error = &TimeoutError{Method: "Get", Duration: time.Second}
+	if want := "timeout: Get did not complete within 1s"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	var timeoutErr interface{ Timeout() bool }
+	if !errors.As(err, &timeoutErr) || !timeoutErr.Timeout() {
+		t.Error("TimeoutError does not report a timeout like net.Error")
+	}
+}
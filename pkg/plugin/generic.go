@@ -0,0 +1,348 @@
+package plugin
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+
+	"github.com/Bo0mer/gentools/pkg/resolution"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// resolveTypeParams returns the type parameters of the discovered generic
+// interface, with their constraints resolved against the generated file.
+func resolveTypeParams(resolver *resolution.Resolver, d resolution.TypeDiscovery) (*ast.FieldList, error) {
+	context := resolution.NewASTFileLocatorContext(d.File, d.Location)
+	context.DeclareTypeParams(d.Spec.TypeParams)
+	resolved := &ast.FieldList{}
+	for _, field := range d.Spec.TypeParams.List {
+		constraint, err := resolver.ResolveType(context, field.Type)
+		if err != nil {
+			return nil, err
+		}
+		resolved.List = append(resolved.List, &ast.Field{Names: field.Names, Type: constraint})
+	}
+	// The type parameters are added to the generated file, which may have
+	// been parsed with another file set, e.g. from the source generated from
+	// a template.
+	return withoutPositions(resolved).(*ast.FieldList), nil
+}
+
+var posType = reflect.TypeOf(token.NoPos)
+
+// withoutPositions returns a deep copy of the node with all positions
+// cleared and without the objects of its identifiers.
+func withoutPositions(node ast.Node) ast.Node {
+	return copyWithoutPositions(reflect.ValueOf(node)).Interface().(ast.Node)
+}
+
+func copyWithoutPositions(v reflect.Value) reflect.Value {
+	if v.Type() == posType {
+		return reflect.Zero(posType)
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if _, ok := v.Interface().(*ast.Object); ok || v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(copyWithoutPositions(v.Elem()))
+		return c
+	case reflect.Interface:
+		c := reflect.New(v.Type()).Elem()
+		if !v.IsNil() {
+			c.Set(copyWithoutPositions(v.Elem()))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			c.Field(i).Set(copyWithoutPositions(v.Field(i)))
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyWithoutPositions(v.Index(i)))
+		}
+		return c
+	}
+	return v
+}
+
+// genericize turns the implementation of a generic interface into a generic
+// implementation. The types and functions declared in the file, which refer
+// to the type parameters or to the interface, directly or through each
+// other, are given the type parameters of the interface, and the references
+// to them and to the interface are instantiated with these parameters:
+//   type loggingStore[K comparable, V any] struct {
+//     next store.Store[K, V]
+//   }
+// Compile-time assertions of the implemented interface are moved into generic
+// functions, as they cannot be instantiated at package level:
+//   func _[K comparable, V any]() {
+//     var _ store.Store[K, V] = new(FakeStore[K, V])
+//   }
+func genericize(file *ast.File, location, name string, params *ast.FieldList) {
+	alias := ""
+	for _, imp := range importSpecs(file) {
+		if path, err := strconv.Unquote(imp.Path.Value); err == nil && path == location {
+			alias = imp.Name.String()
+		}
+	}
+
+	g := &genericizer{
+		alias:   alias,
+		name:    name,
+		params:  map[string]bool{},
+		generic: map[string]bool{},
+	}
+	for _, field := range params.List {
+		for _, param := range field.Names {
+			g.params[param.Name] = true
+			g.args = append(g.args, param.Name)
+		}
+	}
+	g.findGeneric(file)
+
+	decls := []ast.Decl{}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			if d.Tok == token.VAR {
+				var assertions []ast.Decl
+				d.Specs, assertions = g.genericAssertions(d.Specs, params)
+				decls = append(decls, assertions...)
+				if len(d.Specs) == 0 {
+					continue
+				}
+			}
+			if d.Tok == token.TYPE {
+				for _, spec := range d.Specs {
+					spec := spec.(*ast.TypeSpec)
+					if g.generic[spec.Name.Name] {
+						spec.TypeParams = copyTypeParams(params)
+					}
+				}
+			}
+		case *ast.FuncDecl:
+			if d.Recv == nil && g.generic[d.Name.Name] {
+				d.Type.TypeParams = copyTypeParams(params)
+			}
+		}
+		decls = append(decls, decl)
+	}
+	file.Decls = decls
+	g.instantiate(file)
+}
+
+type genericizer struct {
+	alias   string
+	name    string
+	params  map[string]bool
+	args    []string
+	generic map[string]bool
+}
+
+// findGeneric records the types and functions of the file, which have to be
+// generic, until no more are found.
+func (g *genericizer) findGeneric(file *ast.File) {
+	for found := true; found; {
+		found = false
+		mark := func(name string, nodes ...ast.Node) {
+			if g.generic[name] {
+				return
+			}
+			for _, node := range nodes {
+				if node != nil && g.refersToGeneric(node) {
+					g.generic[name] = true
+					found = true
+					return
+				}
+			}
+		}
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				if d.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range d.Specs {
+					spec := spec.(*ast.TypeSpec)
+					mark(spec.Name.Name, spec.Type)
+				}
+			case *ast.FuncDecl:
+				if d.Recv == nil {
+					mark(d.Name.Name, d.Type, d.Body)
+				} else if recv := receiverName(d); recv != "" {
+					// Types whose methods refer to the type parameters have
+					// to be generic as well.
+					mark(recv, d.Type, d.Body)
+				}
+			}
+		}
+	}
+}
+
+// refersToGeneric returns whether the node refers to any of the type
+// parameters, to the interface or to the generic declarations of the file.
+func (g *genericizer) refersToGeneric(node ast.Node) bool {
+	found := false
+	astutil.Apply(node, func(c *astutil.Cursor) bool {
+		if found {
+			return false
+		}
+		if g.isGenericRef(c) {
+			found = true
+			return false
+		}
+		return true
+	}, nil)
+	return found
+}
+
+// isGenericRef returns whether the node at the cursor is a reference to a
+// type parameter, to the interface or to a generic declaration of the file.
+func (g *genericizer) isGenericRef(c *astutil.Cursor) bool {
+	switch n := c.Node().(type) {
+	case *ast.SelectorExpr:
+		id, ok := n.X.(*ast.Ident)
+		return ok && g.alias != "" && id.Name == g.alias && n.Sel.Name == g.name
+	case *ast.Ident:
+		if isDeclaringIdent(c) {
+			return false
+		}
+		if !token.IsIdentifier(n.Name) {
+			// Models build some expressions from source.
+			fset := token.NewFileSet()
+			expr, err := parser.ParseExprFrom(fset, "", n.Name, 0)
+			return err == nil && g.refersToGeneric(expr)
+		}
+		return g.params[n.Name] || g.generic[n.Name]
+	}
+	return false
+}
+
+// instantiate replaces the references to the interface and to the generic
+// declarations of the file with their instantiations.
+func (g *genericizer) instantiate(node ast.Node) {
+	astutil.Apply(node, func(c *astutil.Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.SelectorExpr:
+			if g.isGenericRef(c) {
+				c.Replace(g.instantiation(n))
+				return false
+			}
+		case *ast.Ident:
+			if !token.IsIdentifier(n.Name) {
+				n.Name = g.instantiateSource(n.Name)
+			} else if g.generic[n.Name] && g.isGenericRef(c) {
+				c.Replace(g.instantiation(n))
+			}
+		}
+		return true
+	}, nil)
+}
+
+// instantiateSource replaces the references in an expression built from
+// source, which is returned intact if it cannot be parsed.
+func (g *genericizer) instantiateSource(src string) string {
+	fset := token.NewFileSet()
+	expr, err := parser.ParseExprFrom(fset, "", src, 0)
+	if err != nil {
+		return src
+	}
+	root := &ast.ParenExpr{X: expr}
+	g.instantiate(root)
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, root.X); err != nil {
+		return src
+	}
+	return buf.String()
+}
+
+func (g *genericizer) instantiation(x ast.Expr) ast.Expr {
+	indices := make([]ast.Expr, len(g.args))
+	for i, arg := range g.args {
+		indices[i] = ast.NewIdent(arg)
+	}
+	if len(indices) == 1 {
+		return &ast.IndexExpr{X: x, Index: indices[0]}
+	}
+	return &ast.IndexListExpr{X: x, Indices: indices}
+}
+
+// genericAssertions splits the compile-time assertions, i.e. blank
+// variables, which refer to generic declarations, from the specifications and
+// returns them declared in generic functions.
+func (g *genericizer) genericAssertions(specs []ast.Spec, params *ast.FieldList) ([]ast.Spec, []ast.Decl) {
+	result := []ast.Spec{}
+	var assertions []ast.Decl
+	for _, spec := range specs {
+		value, ok := spec.(*ast.ValueSpec)
+		if !ok || len(value.Names) != 1 || value.Names[0].Name != "_" || !g.refersToGeneric(value) {
+			result = append(result, spec)
+			continue
+		}
+		assertions = append(assertions, &ast.FuncDecl{
+			Name: ast.NewIdent("_"),
+			Type: &ast.FuncType{
+				TypeParams: copyTypeParams(params),
+				Params:     &ast.FieldList{},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.DeclStmt{
+						Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{value}},
+					},
+				},
+			},
+		})
+	}
+	return result, assertions
+}
+
+// isDeclaringIdent returns whether the identifier at the cursor names a
+// declaration, a field, a selected member or a composite literal key, rather
+// than referring to a type or function.
+func isDeclaringIdent(c *astutil.Cursor) bool {
+	switch c.Name() {
+	case "Sel", "Names", "Name", "Label":
+		return true
+	case "Key":
+		_, ok := c.Parent().(*ast.KeyValueExpr)
+		return ok
+	}
+	return false
+}
+
+// receiverName returns the name of the receiver type of the method.
+func receiverName(decl *ast.FuncDecl) string {
+	recvType := decl.Recv.List[0].Type
+	if star, ok := recvType.(*ast.StarExpr); ok {
+		recvType = star.X
+	}
+	if id, ok := recvType.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+func copyTypeParams(params *ast.FieldList) *ast.FieldList {
+	result := &ast.FieldList{}
+	for _, field := range params.List {
+		names := make([]*ast.Ident, len(field.Names))
+		for i, name := range field.Names {
+			names[i] = ast.NewIdent(name.Name)
+		}
+		result.List = append(result.List, &ast.Field{Names: names, Type: field.Type})
+	}
+	return result
+}
@@ -0,0 +1,399 @@
+package plugin
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Bo0mer/gentools/pkg/astgen"
+	"github.com/Bo0mer/gentools/pkg/resolution"
+	"github.com/Bo0mer/gentools/pkg/transformation"
+)
+
+// Options describes the implementations to be generated.
+type Options struct {
+	Selection
+	// Target, if not empty, is the directory of the package to which the
+	// implementations are written. See ResolvePackages.
+	Target string
+	// Output, if not empty, is the name of the single file to which all
+	// implementations are written, instead of one file for each interface.
+	Output string
+	// Args are the additional arguments passed to the plugin.
+	Args []string
+}
+
+// Result describes a generated implementation.
+type Result struct {
+	// Config is the configuration of the implementation.
+	Config Config
+	// Path is the path to the file containing the implementation.
+	Path string
+	// InterfacePath is the path to the file declaring the interface of the
+	// implementation of a struct, or empty for interfaces.
+	InterfacePath string
+}
+
+// Generate generates the implementations of the selected interfaces, found
+// in the package in source, which is either a directory or an import path.
+// All interfaces share the same locator, so every package is parsed only
+// once. Structs named explicitly are implemented through an interface
+// declaring their exported methods.
+func Generate(p Plugin, source string, opts Options) ([]Result, error) {
+	pkgs, err := ResolvePackages(source, opts.Target, p.packageSuffix())
+	if err != nil {
+		return nil, err
+	}
+	results, sources, err := generate(p, pkgs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(pkgs.TargetDir, 0777); err != nil {
+		return nil, fmt.Errorf("error creating target package directory: %v", err)
+	}
+	for outPath, src := range sources {
+		if existing, err := os.ReadFile(outPath); err == nil && bytes.Equal(existing, src) {
+			continue
+		}
+		if err := os.WriteFile(outPath, src, 0666); err != nil {
+			return nil, fmt.Errorf("error writing output source file: %v", err)
+		}
+	}
+	return results, nil
+}
+
+// generate returns the implementations of the selected interfaces, along
+// with the formatted sources of the generated files, keyed by their paths.
+func generate(p Plugin, pkgs Packages, opts Options) ([]Result, map[string][]byte, error) {
+	locator := resolution.NewLocator()
+	discoveries, err := opts.Find(locator, pkgs.SourcePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var results []Result
+	var files []*ast.File
+	sources := map[string][]byte{}
+	fset := token.NewFileSet()
+	for _, d := range discoveries {
+		cfg := Config{
+			InterfacePath: pkgs.SourcePath,
+			InterfaceName: d.Spec.Name.String(),
+			TargetPackage: pkgs.TargetName,
+			TargetPath:    pkgs.TargetPath,
+			Args:          opts.Args,
+			FileSet:       fset,
+		}
+		file, iface, err := build(p, locator, d, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		result := Result{
+			Config: cfg,
+			Path:   filepath.Join(pkgs.TargetDir, opts.Output),
+		}
+		if opts.Output == "" {
+			result.Path = filepath.Join(pkgs.TargetDir, p.FileName(cfg))
+		}
+		if iface != nil {
+			// The interface shares the types of the implementation, which
+			// are renamed when merged, so it is rendered right away.
+			result.InterfacePath = filepath.Join(pkgs.TargetDir, transformation.ToSnakeCase(cfg.InterfaceName)+"_interface.go")
+			if sources[result.InterfacePath], err = renderInterface(iface); err != nil {
+				return nil, nil, err
+			}
+		}
+		results = append(results, result)
+		files = append(files, file)
+	}
+
+	if opts.Output != "" {
+		file, err := merge(pkgs.TargetName, files, fset)
+		if err != nil {
+			return nil, nil, err
+		}
+		src, err := render(p, file, fset, pkgs.SourcePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		sources[filepath.Join(pkgs.TargetDir, opts.Output)] = src
+	} else {
+		for i, file := range files {
+			if sources[results[i].Path], err = render(p, file, fset, pkgs.SourcePath); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return results, sources, nil
+}
+
+// build returns the file containing the implementation of the discovered
+// interface, or of the method set of the discovered struct, along with the
+// file declaring the interface of the struct.
+func build(p Plugin, locator *resolution.Locator, d resolution.TypeDiscovery, cfg Config) (*ast.File, *ast.File, error) {
+	model, err := p.NewModel(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	var strct *structModel
+	var builder Model = model
+	if _, ok := d.Spec.Type.(*ast.StructType); ok {
+		strct = newStructModel(model, cfg)
+		builder = strct
+	}
+	generator := astgen.Generator{
+		Model:    builder,
+		Locator:  locator,
+		Resolver: resolution.NewResolver(builder, locator, cfg.TargetPath),
+	}
+
+	err = generator.ProcessType(d)
+	if err != nil {
+		return nil, nil, err
+	}
+	var typeParams *ast.FieldList
+	if d.Spec.TypeParams != nil && strct == nil {
+		// The type parameters are resolved before the file is built, so
+		// that the packages of their constraints are imported.
+		typeParams, err = resolveTypeParams(generator.Resolver, d)
+		if err != nil {
+			return nil, nil, locator.TypeError(d, err)
+		}
+	}
+	if v, ok := model.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, nil, locator.TypeError(d, err)
+		}
+	}
+	if strct == nil {
+		file := model.Build()
+		if typeParams != nil {
+			genericize(file, cfg.InterfacePath, cfg.InterfaceName, typeParams)
+		}
+		return file, nil, nil
+	}
+	return strct.Build(), strct.BuildInterface(), nil
+}
+
+// render returns the formatted source of the generated file, documenting
+// its package as implementing the interfaces of the package in sourcePath.
+// The positions of the file, if it was parsed, are held by fset.
+func render(p Plugin, file *ast.File, fset *token.FileSet, sourcePath string) ([]byte, error) {
+	if !file.Pos().IsValid() {
+		// The positions of built files, if any, are the ones of the parsed
+		// interfaces, which must not be looked up in fset.
+		fset = token.NewFileSet()
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by %s. DO NOT EDIT.\n\n", p.Name)
+	kind := "implementations"
+	if p.Description != "" {
+		kind = p.Description + " " + kind
+	}
+	fmt.Fprintf(&buf, "// Package %s provides %s of the interfaces of\n// %s.\n", file.Name, kind, sourcePath)
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderInterface returns the formatted source of the file declaring the
+// interface of a struct. The file is shared by the implementations of all
+// plugins, so it names none of them, and its imports are aliased in the
+// order of their paths, so that all plugins render it the same way.
+func renderInterface(file *ast.File) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), file); err != nil {
+		return nil, err
+	}
+	// The file is parsed again, so that renaming its imports leaves the
+	// types of the implementation intact.
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, "", buf.Bytes(), parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	ast.SortImports(fset, parsed)
+
+	aliases := astgen.NewFile(parsed.Name.Name)
+	renames := map[string]string{}
+	for _, imp := range importSpecs(parsed) {
+		location, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		alias := aliases.AddImport("", location)
+		renames[imp.Name.String()] = alias
+		imp.Name.Name = alias
+	}
+	renamed := map[*ast.Ident]bool{}
+	for _, decl := range parsed.Decls {
+		if gen, ok := decl.(*ast.GenDecl); !ok || gen.Tok != token.IMPORT {
+			renameAliases(decl, renames, renamed)
+		}
+	}
+
+	buf.Reset()
+	fmt.Fprint(&buf, "// Code generated by gentools. DO NOT EDIT.\n\n")
+	if err := format.Node(&buf, fset, parsed); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MainPlugin runs a command which generates the implementations of the
+// plugin:
+//   NAME [-h] [FLAGS] SOURCE INTERFACE_NAME[,...] [ARGS...]
+func MainPlugin(p Plugin) {
+	run(p, path.Base(os.Args[0]), os.Args[1:])
+}
+
+// Main runs the gentools command, which generates the implementations of
+// any registered plugin:
+//   gentools [-h] PLUGIN [FLAGS] SOURCE INTERFACE_NAME[,...] [ARGS...]
+// Plugins which are not registered are run from the gentools-PLUGIN
+// executable, if it is found in PATH.
+func Main() {
+	command := path.Base(os.Args[0])
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	fs.Usage = func() {
+		var out io.Writer = os.Stdout
+
+		fmt.Fprintln(out, "A tool that generates implementations of interfaces.")
+		fmt.Fprintf(out, "Usage: %s [-h] PLUGIN [FLAGS] SOURCE INTERFACE_NAME[,...] [ARGS...]\n", command)
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "  Plugins:")
+		for _, p := range Plugins() {
+			fmt.Fprintf(out, "    %-16s %s implementations\n", p.Name, p.Description)
+		}
+		fmt.Fprintln(out, "")
+		fmt.Fprintf(out, "  Run %q for the arguments of a plugin. Other plugins are\n", command+" PLUGIN -h")
+		fmt.Fprintf(out, "  run from the %s-PLUGIN executable found in PATH.\n", command)
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "  Options:")
+		fmt.Fprintln(out, "    -h               Print this text and exit")
+		fmt.Fprintln(out, "")
+	}
+	fs.Parse(os.Args[1:])
+	if fs.NArg() < 1 {
+		log.Fatal("too few arguments provided")
+	}
+
+	name := fs.Arg(0)
+	if p, ok := Lookup(name); ok {
+		run(p, command+" "+name, fs.Args()[1:])
+		return
+	}
+
+	executable, err := exec.LookPath(command + "-" + name)
+	if err != nil {
+		log.Fatalf("unknown plugin: %s", name)
+	}
+	cmd := exec.Command(executable, fs.Args()[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		log.Fatal(err)
+	}
+}
+
+func run(p Plugin, command string, arguments []string) {
+	var opts Options
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	fs.Usage = func() {
+		var out io.Writer = os.Stdout
+
+		fmt.Fprintf(out, "A tool that generates %s implementations of interfaces.\n", p.Description)
+		fmt.Fprintf(out, "Usage: %s [-h] [FLAGS] SOURCE INTERFACE_NAME[,...]", command)
+		if p.Args != "" {
+			fmt.Fprint(out, " ", p.Args)
+		}
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "  Arguments:")
+		fmt.Fprintln(out, "    SOURCE           Path to the directory, or import path, of the package")
+		fmt.Fprintln(out, "                     containing the interface")
+		fmt.Fprintln(out, "    INTERFACE_NAME   Name of the interface which will be implemented, or a")
+		fmt.Fprintln(out, "                     glob pattern, e.g. *Store, matching exported interfaces")
+		for _, line := range p.ArgsHelp {
+			fmt.Fprintln(out, "    "+line)
+		}
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "  Options:")
+		fmt.Fprintln(out, "    -h               Print this text and exit")
+		fs.VisitAll(func(f *flag.Flag) {
+			if f.Name != "h" {
+				name, usage := flag.UnquoteUsage(f)
+				fmt.Fprintf(out, "    -%-15s %s\n", strings.TrimSpace(f.Name+" "+name), usage)
+			}
+		})
+		fmt.Fprintln(out, "")
+	}
+	opts.SetFlags(fs)
+	fs.StringVar(&opts.Output, "output", "", "Write all implementations to `FILE` in the target package")
+	fs.StringVar(&opts.Target, "target", "", "Write the implementations to the package in `DIR`")
+	if p.Flags != nil {
+		p.Flags(fs)
+	}
+	fs.Parse(arguments)
+
+	names := 1
+	if opts.All {
+		names = 0
+	}
+	if fs.NArg() < 1+names {
+		log.Fatal("too few arguments provided")
+	}
+	if fs.NArg() > 1+names && p.Args == "" {
+		log.Fatal("too many arguments provided")
+	}
+	switch {
+	case opts.Regexp:
+		opts.Patterns = []string{fs.Arg(1)}
+	case !opts.All:
+		opts.Patterns = strings.Split(fs.Arg(1), ",")
+	}
+	opts.Args = fs.Args()[1+names:]
+
+	results, err := Generate(p, fs.Arg(0), opts)
+	if err != nil {
+		// Errors are reported like compiler diagnostics, without the
+		// timestamp of the log, so that editors can jump to them.
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	wd, _ := os.Getwd()
+	for _, r := range results {
+		outPath := r.Path
+		if rel, err := filepath.Rel(wd, outPath); err == nil {
+			outPath = rel
+		}
+		fmt.Printf("Wrote %s implementation of %q to %q\n", p.Description, r.Config.InterfacePath+"."+r.Config.InterfaceName, outPath)
+		if r.InterfacePath != "" {
+			ifacePath := r.InterfacePath
+			if rel, err := filepath.Rel(wd, ifacePath); err == nil {
+				ifacePath = rel
+			}
+			fmt.Printf("Wrote interface of %q to %q\n", r.Config.InterfacePath+"."+r.Config.InterfaceName, ifacePath)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package plugin
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Bo0mer/gentools/pkg/astgen"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+const testdataPath = "github.com/Bo0mer/gentools/pkg/plugin/testdata"
+
+// forwardingPlugin generates implementations which forward every call to
+// the wrapped implementation:
+//   func (m *forwardingService) Get(id string) (*User, error) {
+//     return m.next.Get(id)
+//   }
+var forwardingPlugin = Plugin{
+	Name:        "forwardgen",
+	Description: "forwarding",
+	FileName: func(cfg Config) string {
+		return fmt.Sprintf("forwarding_%s.go", cfg.InterfaceName)
+	},
+	NewModel: newForwardingModel,
+}
+
+type forwardingModel struct {
+	fileBuilder *astgen.File
+	structName  string
+}
+
+func newForwardingModel(cfg Config) (Model, error) {
+	m := &forwardingModel{
+		fileBuilder: astgen.NewFile(cfg.TargetPackage),
+		structName:  "forwarding" + cfg.InterfaceName,
+	}
+	sourcePackageAlias := m.AddImport("", cfg.InterfacePath)
+
+	strct := astgen.NewStruct(m.structName)
+	strct.AddField("next", sourcePackageAlias, cfg.InterfaceName)
+	m.fileBuilder.AppendDeclaration(strct)
+	m.fileBuilder.AppendDeclaration(astgen.NewInterfaceAssertion(sourcePackageAlias, cfg.InterfaceName, m.structName))
+	return m, nil
+}
+
+func (m *forwardingModel) AddImport(pkgName, location string) string {
+	return m.fileBuilder.AddImport(pkgName, location)
+}
+
+func (m *forwardingModel) AddMethod(method *astgen.MethodConfig) error {
+	b := astgen.NewMethod(method.MethodName, "m", m.structName)
+	b.SetDoc(method.WrapperDoc(fmt.Sprintf("%s forwards the call to the wrapped method.", method.MethodName)))
+	b.SetType(&ast.FuncType{
+		Params:  &ast.FieldList{List: method.MethodParams},
+		Results: &ast.FieldList{List: method.MethodResults},
+	})
+	invocation := astgen.NewMethodInvocation(method)
+	invocation.SetReceiver(&ast.SelectorExpr{
+		X:   ast.NewIdent("m"),
+		Sel: ast.NewIdent("next"),
+	})
+	b.AddStatement(invocation.BuildReturn())
+	m.fileBuilder.AppendDeclaration(b)
+	return nil
+}
+
+func (m *forwardingModel) Build() *ast.File {
+	return m.fileBuilder.Build()
+}
+
+// TestGenerateGolden compares the generated files with the golden files in
+// testdata/golden/NAME. Run the test with -update to rewrite them.
+func TestGenerateGolden(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		patterns []string
+		output   string
+	}{
+		// The constraints of the type parameters refer to an aliased
+		// import and to an inline union.
+		{"generic", "generic", []string{"Index"}, ""},
+		// Both interfaces refer to packages named store, which are
+		// imported with different aliases in each of the built files.
+		{"merge", "merge", []string{"Users", "Orders"}, "forwarding.go"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := filepath.Join("testdata", "golden", test.name)
+			pkgs := Packages{
+				SourcePath: testdataPath + "/" + test.source,
+				TargetDir:  dir,
+				TargetPath: testdataPath + "/" + test.source + "/" + test.source + "mws",
+				TargetName: test.source + "mws",
+			}
+			opts := Options{
+				Selection: Selection{Patterns: test.patterns},
+				Output:    test.output,
+			}
+			_, sources, err := generate(forwardingPlugin, pkgs, opts)
+			if err != nil {
+				t.Fatalf("generate() error = %v", err)
+			}
+
+			if *update {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.MkdirAll(dir, 0777); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for outPath, src := range sources {
+				golden := outPath + ".golden"
+				if *update {
+					if err := os.WriteFile(golden, src, 0666); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("error reading golden file: %v", err)
+				}
+				if !bytes.Equal(src, want) {
+					t.Errorf("generated %s differs from %s:\n%s", filepath.Base(outPath), golden, src)
+				}
+			}
+			goldens, err := filepath.Glob(filepath.Join(dir, "*.golden"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(goldens) != len(sources) {
+				t.Errorf("generated %d files, want %d", len(sources), len(goldens))
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package plugin
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/Bo0mer/gentools/pkg/astgen"
+)
+
+// generatedAlias matches the aliases allocated by astgen.File for imports
+// added without a package name.
+var generatedAlias = regexp.MustCompile(`^alias[0-9]+$`)
+
+// merge returns a single file in the specified package, declaring everything
+// declared in the files. The imports of the files are merged, renaming the
+// aliases which refer to different packages in different files.
+func merge(packageName string, files []*ast.File, fset *token.FileSet) (*ast.File, error) {
+	merged := astgen.NewFile(packageName)
+
+	var decls []*printer.CommentedNode
+	parsed := false
+	renamed := map[*ast.Ident]bool{}
+	for _, file := range files {
+		// The imports of built files are in random order, so they are
+		// sorted to allocate the same aliases every time.
+		specs := importSpecs(file)
+		sort.Slice(specs, func(i, j int) bool {
+			return specs[i].Path.Value < specs[j].Path.Value
+		})
+		renames := map[string]string{}
+		for _, imp := range specs {
+			location, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				continue
+			}
+			alias, name := imp.Name.String(), imp.Name.String()
+			if generatedAlias.MatchString(alias) {
+				name = ""
+			}
+			renames[alias] = merged.AddImport(name, location)
+		}
+
+		for _, decl := range file.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+				continue
+			}
+			renameAliases(decl, renames, renamed)
+			decls = append(decls, &printer.CommentedNode{Node: decl, Comments: file.Comments})
+		}
+		parsed = parsed || file.Pos().IsValid()
+	}
+
+	file := merged.Build()
+	if !parsed {
+		for _, decl := range decls {
+			file.Decls = append(file.Decls, decl.Node.(ast.Decl))
+		}
+		return file, nil
+	}
+
+	// The positions of different parsed files cannot be mixed, so their
+	// declarations are rendered along with their comments and parsed again.
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, err
+	}
+	for _, decl := range decls {
+		buf.WriteString("\n\n")
+		if err := format.Node(&buf, fset, decl); err != nil {
+			return nil, err
+		}
+	}
+	return parser.ParseFile(fset, "", buf.Bytes(), parser.ParseComments)
+}
+
+// importSpecs returns the import specifications declared in the file.
+func importSpecs(file *ast.File) []*ast.ImportSpec {
+	var specs []*ast.ImportSpec
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			specs = append(specs, spec.(*ast.ImportSpec))
+		}
+	}
+	return specs
+}
+
+// renameAliases renames the package aliases qualifying identifiers in the
+// declaration. Identifiers may be shared by multiple expressions, so the
+// renamed ones are recorded in order to be renamed only once.
+func renameAliases(decl ast.Decl, renames map[string]string, renamed map[*ast.Ident]bool) {
+	ast.Inspect(decl, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if id, ok := sel.X.(*ast.Ident); ok && !renamed[id] {
+			if alias, ok := renames[id.Name]; ok {
+				id.Name = alias
+				renamed[id] = true
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,150 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Packages describes where the interfaces are found and where their
+// implementations are written.
+type Packages struct {
+	// SourcePath is the import path of the package of the interfaces.
+	SourcePath string
+	// TargetDir is the directory of the package of the implementations.
+	TargetDir string
+	// TargetPath is the import path of the package of the implementations,
+	// against which the visibility of the referred types is checked.
+	TargetPath string
+	// TargetName is the name of the package of the implementations.
+	TargetName string
+}
+
+// ResolvePackages returns the packages of the interfaces and of their
+// implementations. The source is either the directory of the package of the
+// interfaces or its import path, e.g. io or database/sql/driver, which
+// allows wrapping interfaces of packages one does not own.
+//
+// The implementations are written to the package in the target directory,
+// named after the Go files already in it, or after the directory itself.
+// An empty target defaults to a package named after the package of the
+// interfaces with the suffix appended, created in the source directory, or
+// in the working directory when the source is an import path.
+func ResolvePackages(source, target, suffix string) (Packages, error) {
+	var pkgs Packages
+	var sourceDir, parentDir string
+	var err error
+	if isDir(source) {
+		sourceDir, err = filepath.Abs(source)
+		if err != nil {
+			return Packages{}, fmt.Errorf("error determining absolute path to source directory: %v", err)
+		}
+		pkgs.SourcePath, err = dirToImport(sourceDir)
+		if err != nil {
+			return Packages{}, fmt.Errorf("error resolving import path of source directory: %v", err)
+		}
+		parentDir = sourceDir
+	} else {
+		pkgs.SourcePath, sourceDir, err = loadImport(source)
+		if err != nil {
+			return Packages{}, err
+		}
+		parentDir, err = os.Getwd()
+		if err != nil {
+			return Packages{}, fmt.Errorf("error determining working directory: %v", err)
+		}
+	}
+
+	if target == "" {
+		pkgs.TargetName = path.Base(pkgs.SourcePath) + suffix
+		pkgs.TargetDir = filepath.Join(parentDir, pkgs.TargetName)
+		if parentDir == sourceDir {
+			pkgs.TargetPath = pkgs.SourcePath + "/" + pkgs.TargetName
+			return pkgs, nil
+		}
+		pkgs.TargetPath, err = dirToImport(pkgs.TargetDir)
+		if err != nil {
+			return Packages{}, fmt.Errorf("error resolving import path of target directory: %v", err)
+		}
+		return pkgs, nil
+	}
+
+	pkgs.TargetDir, err = filepath.Abs(target)
+	if err != nil {
+		return Packages{}, fmt.Errorf("error determining absolute path to target directory: %v", err)
+	}
+	if pkgs.TargetDir == sourceDir {
+		return Packages{}, errors.New("implementations cannot be written to the package of the interfaces")
+	}
+	pkgs.TargetName, err = packageName(pkgs.TargetDir)
+	if err != nil {
+		return Packages{}, err
+	}
+	pkgs.TargetPath, err = dirToImport(pkgs.TargetDir)
+	if err != nil {
+		return Packages{}, fmt.Errorf("error resolving import path of target directory: %v", err)
+	}
+	return pkgs, nil
+}
+
+func isDir(name string) bool {
+	fi, err := os.Stat(name)
+	return err == nil && fi.IsDir()
+}
+
+func dirToImport(p string) (string, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName,
+	}
+	ps, err := packages.Load(cfg, p)
+	if err != nil {
+		return "", err
+	}
+	if len(ps) == 0 {
+		return "", errors.New("could not find package to import")
+	}
+	return ps[0].PkgPath, nil
+}
+
+// loadImport returns the import path and the directory of the package
+// imported by the specified path.
+func loadImport(importPath string) (string, string, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles,
+	}
+	ps, err := packages.Load(cfg, importPath)
+	if err != nil {
+		return "", "", err
+	}
+	if len(ps) > 0 && len(ps[0].Errors) > 0 {
+		return "", "", fmt.Errorf("error loading package %q: %v", importPath, ps[0].Errors[0])
+	}
+	if len(ps) == 0 || len(ps[0].GoFiles) == 0 {
+		return "", "", fmt.Errorf("could not find package %q", importPath)
+	}
+	return ps[0].PkgPath, filepath.Dir(ps[0].GoFiles[0]), nil
+}
+
+// packageName returns the name of the package in the specified directory,
+// or the name of the directory if it contains no Go files yet.
+func packageName(dir string) (string, error) {
+	notTest := func(fi fs.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, notTest, parser.PackageClauseOnly)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("error reading target package: %v", err)
+	}
+	for name := range pkgs {
+		return name, nil
+	}
+	return filepath.Base(dir), nil
+}
@@ -0,0 +1,162 @@
+// Package plugin provides the extension API of gentools.
+//
+// Every kind of generated implementations, e.g. logging or tracing
+// middlewares, is a Plugin that creates the Model generating the
+// implementation of an interface. Plugins are registered, usually from the
+// init func of the package defining them, and are run by the gentools
+// command. Plugins defined in other modules can be run by a command built
+// with MainPlugin, named gentools-NAME, which the gentools command runs for
+// plugins it does not know about:
+//
+//   package main
+//
+//   import (
+//     "github.com/Bo0mer/gentools/pkg/plugin"
+//     "github.com/Bo0mer/gentools/pkg/transformation"
+//   )
+//
+//   func main() {
+//     plugin.MainPlugin(plugin.Plugin{
+//       Name:        "auditgen",
+//       Description: "auditing",
+//       FileName: func(cfg plugin.Config) string {
+//         return "auditing_" + transformation.ToSnakeCase(cfg.InterfaceName) + ".go"
+//       },
+//       NewModel: newAuditingModel,
+//     })
+//   }
+package plugin
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"sync"
+
+	"github.com/Bo0mer/gentools/pkg/astgen"
+	"github.com/Bo0mer/gentools/pkg/resolution"
+)
+
+// Model generates the implementation of an interface. The methods of the
+// interface, with their types resolved against the imports added to the
+// model, are added one by one before the generated file is built.
+type Model interface {
+	resolution.Importer
+	astgen.ModelBuilder
+
+	// Build returns the generated file.
+	Build() *ast.File
+}
+
+// Validator is implemented by models which can detect some errors only once
+// all methods have been added, e.g. annotations that refer to other methods.
+type Validator interface {
+	// Validate is called before the generated file is built.
+	Validate() error
+}
+
+// InterfaceLocalizer is implemented by models which refer to the interface
+// declared for a struct by themselves. The models of structs refer to the
+// struct in place of the interface otherwise, and the references are
+// replaced once the file is built, leaving the types of the methods intact.
+type InterfaceLocalizer interface {
+	// LocalizeInterface makes the model refer to the interface with the
+	// specified name, declared in the target package. It is called before
+	// the methods are added.
+	LocalizeInterface(name string)
+}
+
+// Config describes the implementation to be generated.
+type Config struct {
+	// InterfacePath is the import path of the package of the interface.
+	InterfacePath string
+	// InterfaceName is the name of the interface.
+	InterfaceName string
+	// TargetPackage is the name of the package of the generated file.
+	TargetPackage string
+	// TargetPath is the import path of the package of the generated file.
+	TargetPath string
+	// Args are the additional arguments passed to the plugin.
+	Args []string
+	// FileSet holds the positions of the files parsed by the models, e.g.
+	// from the sources generated from templates. The generated files are
+	// rendered with it.
+	FileSet *token.FileSet
+}
+
+// Plugin describes a kind of generated implementations.
+type Plugin struct {
+	// Name is the name of the plugin, e.g. logen.
+	Name string
+	// Description describes the generated implementations, e.g. logging.
+	Description string
+	// Args describes the additional arguments of the plugin, if any, e.g.
+	// [PROVIDER].
+	Args string
+	// ArgsHelp explains the additional arguments, one line each.
+	ArgsHelp []string
+	// PackageSuffix is appended to the name of the package of the interface
+	// to name the package of the generated file. Defaults to mws.
+	PackageSuffix string
+	// Flags, if not nil, defines the flags of the plugin on the flag set.
+	// The flags are parsed before NewModel is called.
+	Flags func(fs *flag.FlagSet)
+	// FileName returns the name of the generated file.
+	FileName func(cfg Config) string
+	// NewModel creates the model generating the implementation.
+	NewModel func(cfg Config) (Model, error)
+}
+
+func (p Plugin) packageSuffix() string {
+	if p.PackageSuffix == "" {
+		return "mws"
+	}
+	return p.PackageSuffix
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Plugin{}
+)
+
+// Register makes the plugin available by its name. It panics if the name,
+// FileName or NewModel of the plugin is missing, or if a plugin with the
+// same name is already registered.
+func Register(p Plugin) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if p.Name == "" || p.FileName == nil || p.NewModel == nil {
+		panic(fmt.Sprintf("plugin: incomplete plugin %q", p.Name))
+	}
+	if _, dup := registry[p.Name]; dup {
+		panic(fmt.Sprintf("plugin: Register called twice for plugin %q", p.Name))
+	}
+	registry[p.Name] = p
+}
+
+// Lookup returns the plugin registered with the specified name.
+func Lookup(name string) (Plugin, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	p, ok := registry[name]
+	return p, ok
+}
+
+// Plugins returns all registered plugins, sorted by name.
+func Plugins() []Plugin {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	var plugins []Plugin
+	for _, p := range registry {
+		plugins = append(plugins, p)
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name < plugins[j].Name
+	})
+	return plugins
+}
@@ -0,0 +1,166 @@
+package plugin
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/Bo0mer/gentools/pkg/astgen"
+	"github.com/Bo0mer/gentools/pkg/resolution"
+)
+
+// Selection selects the interfaces of a package to generate implementations
+// of.
+type Selection struct {
+	// Patterns are the names of the interfaces, or glob patterns matching
+	// the names of exported interfaces, e.g. Service or *Store.
+	Patterns []string
+	// Regexp reports whether Patterns are regular expressions matching the
+	// names of exported interfaces instead.
+	Regexp bool
+	// All selects all exported interfaces, in which case Patterns must be
+	// empty. Neither All nor the patterns select interfaces which cannot be
+	// implemented by other packages.
+	All bool
+	// Annotation, if not empty, keeps only the interfaces whose doc comment
+	// contains the gentools annotation with that name, e.g.
+	//   //gentools:generate
+	Annotation string
+}
+
+// SetFlags defines the flags of the selection on the flag set.
+func (s *Selection) SetFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&s.All, "all", false, "Select all exported interfaces, INTERFACE_NAME is omitted")
+	fs.BoolVar(&s.Regexp, "regexp", false, "Interpret INTERFACE_NAME as a regular expression")
+	fs.StringVar(&s.Annotation, "annotated", "", "Select only interfaces annotated with //gentools:`NAME`")
+}
+
+// Find returns the selected interfaces of the package in the specified
+// location, in the order in which they were selected.
+func (s Selection) Find(locator *resolution.Locator, location string) ([]resolution.TypeDiscovery, error) {
+	if s.All && len(s.Patterns) > 0 {
+		return nil, errors.New("interface names cannot be combined with selecting all interfaces")
+	}
+	if !s.All && len(s.Patterns) == 0 {
+		return nil, errors.New("no interfaces selected")
+	}
+
+	exported, err := locator.FindInterfaceTypes(location)
+	if err != nil {
+		return nil, err
+	}
+	// Interfaces selected by patterns which cannot be implemented are
+	// skipped, while the ones named explicitly are reported when generated.
+	exported, err = implementable(locator, exported)
+	if err != nil {
+		return nil, err
+	}
+
+	var discoveries []resolution.TypeDiscovery
+	if s.All {
+		discoveries = exported
+	} else {
+		discoveries, err = s.match(locator, location, exported)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.Annotation != "" {
+		var annotated []resolution.TypeDiscovery
+		for _, d := range discoveries {
+			if astgen.ParseAnnotations(d.Doc()).Has(s.Annotation) {
+				annotated = append(annotated, d)
+			}
+		}
+		discoveries = annotated
+	}
+	if len(discoveries) == 0 {
+		return nil, fmt.Errorf("no interfaces selected in %q", location)
+	}
+	return discoveries, nil
+}
+
+// implementable returns the discovered interfaces which can be implemented by
+// other packages, i.e. which are neither sealed by unexported methods nor
+// restricted by type constraints.
+func implementable(locator *resolution.Locator, discoveries []resolution.TypeDiscovery) ([]resolution.TypeDiscovery, error) {
+	var result []resolution.TypeDiscovery
+	for _, d := range discoveries {
+		ok, err := locator.IsImplementable(d)
+		if err != nil {
+			return nil, locator.TypeError(d, err)
+		}
+		if ok {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+// match returns the interfaces matching the patterns, each one only once.
+func (s Selection) match(locator *resolution.Locator, location string, exported []resolution.TypeDiscovery) ([]resolution.TypeDiscovery, error) {
+	var discoveries []resolution.TypeDiscovery
+	selected := map[string]bool{}
+	add := func(d resolution.TypeDiscovery) {
+		if name := d.Spec.Name.String(); !selected[name] {
+			selected[name] = true
+			discoveries = append(discoveries, d)
+		}
+	}
+
+	for _, pattern := range s.Patterns {
+		matches, err := s.matcher(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if matches == nil {
+			context := resolution.NewSingleLocationContext(location)
+			d, err := locator.FindIdentType(context, ast.NewIdent(pattern))
+			if err != nil {
+				return nil, err
+			}
+			add(d)
+			continue
+		}
+
+		found := false
+		for _, d := range exported {
+			if matches(d.Spec.Name.String()) {
+				add(d)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no interfaces matching %q found in %q", pattern, location)
+		}
+	}
+	return discoveries, nil
+}
+
+// matcher returns the func reporting whether a name matches the pattern, or
+// nil if the pattern is a plain name.
+func (s Selection) matcher(pattern string) (func(name string) bool, error) {
+	if s.Regexp {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid interface name pattern %q: %v", pattern, err)
+		}
+		return re.MatchString, nil
+	}
+
+	if !strings.ContainsAny(pattern, `*?[\`) {
+		return nil, nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid interface name pattern %q: %v", pattern, err)
+	}
+	return func(name string) bool {
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}, nil
+}
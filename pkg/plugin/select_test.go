@@ -0,0 +1,85 @@
+package plugin
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Bo0mer/gentools/pkg/resolution"
+)
+
+func TestSelectionFind(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection Selection
+		want      []string
+	}{
+		{"all", Selection{All: true}, []string{"Store", "UserStore"}},
+		{"glob", Selection{Patterns: []string{"*Store"}}, []string{"Store", "UserStore"}},
+		{"regexp", Selection{Patterns: []string{".*Only|Ext.*"}, Regexp: true}, nil},
+		{"names", Selection{Patterns: []string{"UserStore", "Store"}}, []string{"UserStore", "Store"}},
+		// Sealed and constraint-only interfaces match no patterns.
+		{"unimplementable regexp", Selection{Patterns: []string{"(Sealed|Int)?Store"}, Regexp: true}, []string{"Store"}},
+		{"unimplementable glob", Selection{Patterns: []string{"Sealed*"}}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			discoveries, err := test.selection.Find(resolution.NewLocator(), testdataPath+"/selection")
+			if test.want == nil {
+				if err == nil {
+					t.Fatalf("Find() selected %d interfaces, want none", len(discoveries))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Find() error = %v", err)
+			}
+			var got []string
+			for _, d := range discoveries {
+				got = append(got, d.Spec.Name.String())
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Find() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSelectionFindIgnoresTestFiles(t *testing.T) {
+	for _, name := range []string{"TestOnly", "FakeStore", "External"} {
+		t.Run(name, func(t *testing.T) {
+			selection := Selection{Patterns: []string{name}}
+			if _, err := selection.Find(resolution.NewLocator(), testdataPath+"/selection"); err == nil {
+				t.Fatalf("Find() selected %s declared by a test file", name)
+			}
+		})
+	}
+}
+
+func TestGenerateReportsUnimplementableNamedExplicitly(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"SealedStore", "'seal' is not exported"},
+		{"OrderedStore", "restricted by type constraint '~int | ~string'"},
+		{"IntStore", "restricted by type constraint 'int'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pkgs := Packages{
+				SourcePath: testdataPath + "/selection",
+				TargetDir:  t.TempDir(),
+				TargetPath: testdataPath + "/selection/selectionmws",
+				TargetName: "selectionmws",
+			}
+			opts := Options{Selection: Selection{Patterns: []string{test.name}}}
+			_, _, err := generate(forwardingPlugin, pkgs, opts)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("generate() error = %v, want it to contain %q", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package plugin
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+
+	"github.com/Bo0mer/gentools/pkg/astgen"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// structModel generates the implementation of the method set of a struct.
+// As the struct is not an interface, the implementation refers to an
+// interface named after the struct, declaring its methods, which is added to
+// the generated package.
+type structModel struct {
+	Model
+	cfg       Config
+	localized bool
+	methods   []*astgen.MethodConfig
+	imports   []*ast.ImportSpec
+}
+
+func newStructModel(model Model, cfg Config) *structModel {
+	m := &structModel{
+		Model: model,
+		cfg:   cfg,
+	}
+	if localizer, ok := model.(InterfaceLocalizer); ok {
+		localizer.LocalizeInterface(cfg.InterfaceName)
+		m.localized = true
+	}
+	return m
+}
+
+func (m *structModel) AddMethod(method *astgen.MethodConfig) error {
+	m.methods = append(m.methods, method)
+	return m.Model.AddMethod(method)
+}
+
+func (m *structModel) Build() *ast.File {
+	file := m.Model.Build()
+	if !m.localized {
+		m.localizeInterface(file)
+	}
+	m.imports = importSpecs(file)
+	pruneImports(file)
+	return file
+}
+
+// BuildInterface returns the file declaring the interface, which is shared
+// by all implementations of the struct in the package. Its types are
+// resolved against the imports of the implementation, so it must be called
+// after Build.
+func (m *structModel) BuildInterface() *ast.File {
+	iface := astgen.NewInterface(m.cfg.InterfaceName)
+	iface.SetDoc(fmt.Sprintf("%s has the exported methods of *%s.%s.", m.cfg.InterfaceName, m.cfg.InterfacePath, m.cfg.InterfaceName))
+	for _, method := range m.methods {
+		iface.AddMethod(method.MethodName, method.Signature)
+	}
+
+	file := &ast.File{
+		Name: ast.NewIdent(m.cfg.TargetPackage),
+	}
+	if len(m.imports) > 0 {
+		imports := &ast.GenDecl{
+			Tok:    token.IMPORT,
+			Lparen: token.Pos(1),
+		}
+		for _, imp := range m.imports {
+			imports.Specs = append(imports.Specs, imp)
+		}
+		file.Decls = append(file.Decls, imports)
+	}
+	file.Decls = append(file.Decls, iface.Build())
+	pruneImports(file)
+	return file
+}
+
+// localizeInterface replaces the references to the struct, made by the
+// model in place of the interface, with references to the interface
+// declared in the generated file, leaving the types of the methods intact.
+func (m *structModel) localizeInterface(file *ast.File) {
+	alias := ""
+	for _, imp := range importSpecs(file) {
+		if location, err := strconv.Unquote(imp.Path.Value); err == nil && location == m.cfg.InterfacePath {
+			alias = imp.Name.String()
+		}
+	}
+	if alias == "" {
+		return
+	}
+
+	methodTypes := map[ast.Node]bool{}
+	for _, method := range m.methods {
+		for _, field := range append(append([]*ast.Field{}, method.MethodParams...), method.MethodResults...) {
+			ast.Inspect(field.Type, func(n ast.Node) bool {
+				methodTypes[n] = true
+				return true
+			})
+		}
+	}
+
+	astutil.Apply(file, func(c *astutil.Cursor) bool {
+		sel, ok := c.Node().(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != m.cfg.InterfaceName || methodTypes[sel] {
+			return true
+		}
+		if id, ok := sel.X.(*ast.Ident); ok && id.Name == alias {
+			c.Replace(ast.NewIdent(m.cfg.InterfaceName))
+			return false
+		}
+		return true
+	}, nil)
+}
+
+// pruneImports removes the imports which are not referred to in the file.
+func pruneImports(file *ast.File) {
+	used := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+
+	decls := []ast.Decl{}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			decls = append(decls, decl)
+			continue
+		}
+		specs := []ast.Spec{}
+		for _, spec := range gen.Specs {
+			if used[spec.(*ast.ImportSpec).Name.String()] {
+				specs = append(specs, spec)
+			}
+		}
+		if len(specs) > 0 {
+			gen.Specs = specs
+			decls = append(decls, gen)
+		}
+	}
+	file.Decls = decls
+}
@@ -0,0 +1,7 @@
+// Package constraints declares the constraints of the generic interfaces.
+package constraints
+
+// Ordered is satisfied by the ordered types.
+type Ordered interface {
+	~int | ~int64 | ~string
+}
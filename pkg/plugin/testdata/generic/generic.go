@@ -0,0 +1,18 @@
+// Package generic declares a constrained generic interface.
+package generic
+
+import (
+	"context"
+	"fmt"
+
+	order "github.com/Bo0mer/gentools/pkg/plugin/testdata/generic/constraints"
+)
+
+// Index indexes values by ordered keys.
+type Index[K order.Ordered, V fmt.Stringer, N interface{ ~int | ~int64 }] interface {
+	// Get returns the value with the key.
+	Get(ctx context.Context, key K) (V, error)
+	Put(ctx context.Context, key K, value V) error
+	Range(ctx context.Context, from, to K) ([]V, error)
+	Len() N
+}
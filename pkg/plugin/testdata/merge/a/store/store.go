@@ -0,0 +1,5 @@
+// Package store declares the users.
+package store
+
+// User is a user.
+type User struct{}
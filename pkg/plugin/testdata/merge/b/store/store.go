@@ -0,0 +1,5 @@
+// Package store declares the orders.
+package store
+
+// Order is an order.
+type Order struct{}
@@ -0,0 +1,22 @@
+// Package merge declares interfaces referring to different packages with
+// the same name.
+package merge
+
+import (
+	users "github.com/Bo0mer/gentools/pkg/plugin/testdata/merge/a/store"
+	orders "github.com/Bo0mer/gentools/pkg/plugin/testdata/merge/b/store"
+)
+
+// Users stores users.
+type Users interface {
+	// Get returns the user with the id.
+	Get(id string) (*users.User, error)
+}
+
+// Orders stores orders.
+type Orders interface {
+	// Get returns the order with the id.
+	Get(id string) (*orders.Order, error)
+	// Owner returns the user who placed the order.
+	Owner(order *orders.Order) (*users.User, error)
+}
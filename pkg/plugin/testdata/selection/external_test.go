@@ -0,0 +1,6 @@
+package selection_test
+
+// External is declared by an external test package.
+type External interface {
+	Do() error
+}
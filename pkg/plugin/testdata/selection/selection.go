@@ -0,0 +1,37 @@
+// Package selection declares the interfaces selected in tests.
+package selection
+
+// Store is an exported interface.
+type Store interface {
+	Get(key string) (string, error)
+}
+
+// UserStore is an exported interface.
+type UserStore interface {
+	User(id string) (string, error)
+}
+
+type cache interface {
+	Get(key string) (string, bool)
+}
+
+type sealer interface {
+	seal()
+}
+
+// SealedStore is sealed by the unexported method of an embedded interface.
+type SealedStore interface {
+	Store
+	sealer
+}
+
+// OrderedStore is restricted by a union of type terms.
+type OrderedStore interface {
+	~int | ~string
+}
+
+// IntStore is restricted by an embedded type other than an interface.
+type IntStore interface {
+	int
+	Get(key string) (string, error)
+}
@@ -0,0 +1,12 @@
+package selection
+
+// TestOnly is declared by a test file of the package.
+type TestOnly interface {
+	Do() error
+}
+
+// FakeStore is declared by a test file of the package.
+type FakeStore interface {
+	Store
+	Calls() int
+}
@@ -0,0 +1,125 @@
+package resolution
+
+import (
+	"go/ast"
+)
+
+// IsComparable returns whether values of the specified type, as seen in the
+// specified context, can be compared with == and thus be used as map keys.
+// Interface types, including any and error, are not considered comparable,
+// as comparing them panics if their dynamic types are not.
+func (l *Locator) IsComparable(context *LocatorContext, astType ast.Expr) (bool, error) {
+	switch t := astType.(type) {
+	case *ast.StarExpr, *ast.ChanType:
+		return true, nil
+	case *ast.FuncType, *ast.MapType, *ast.Ellipsis, *ast.InterfaceType:
+		return false, nil
+	case *ast.ArrayType:
+		// Slices are not comparable, arrays are if their elements are.
+		if t.Len == nil {
+			return false, nil
+		}
+		return l.IsComparable(context, t.Elt)
+	case *ast.StructType:
+		for _, field := range t.Fields.List {
+			ok, err := l.IsComparable(context, field.Type)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case *ast.ParenExpr:
+		return l.IsComparable(context, t.X)
+	case *ast.Ident:
+		if param, ok := context.typeParams[t.Name]; ok {
+			return l.isComparableConstraint(param.context, param.Constraint)
+		}
+		predeclared, err := l.isPredeclared(context, t.Name)
+		if err != nil {
+			return false, err
+		}
+		if predeclared {
+			return t.Name != "any" && t.Name != "error", nil
+		}
+		discovery, err := l.FindIdentType(context, t)
+		if err != nil {
+			return false, err
+		}
+		return l.IsComparable(NewASTFileLocatorContext(discovery.File, discovery.Location), discovery.Spec.Type)
+	case *ast.SelectorExpr:
+		if _, ok := t.X.(*ast.Ident); !ok {
+			return false, nil
+		}
+		discovery, err := l.FindSelectorType(context, t)
+		if err != nil {
+			return false, err
+		}
+		return l.IsComparable(NewASTFileLocatorContext(discovery.File, discovery.Location), discovery.Spec.Type)
+	}
+	return false, nil
+}
+
+// isComparableConstraint returns whether all types satisfying the constraint
+// of a type parameter are comparable, i.e. whether it embeds comparable or
+// restricts its type set to comparable types.
+func (l *Locator) isComparableConstraint(context *LocatorContext, constraint ast.Expr) (bool, error) {
+	switch t := constraint.(type) {
+	case *ast.BinaryExpr:
+		ok, err := l.isComparableConstraint(context, t.X)
+		if err != nil || !ok {
+			return false, err
+		}
+		return l.isComparableConstraint(context, t.Y)
+	case *ast.UnaryExpr:
+		return l.IsComparable(context, t.X)
+	case *ast.InterfaceType:
+		for _, field := range t.Methods.List {
+			if _, ok := field.Type.(*ast.FuncType); ok {
+				continue
+			}
+			ok, err := l.isComparableConstraint(context, field.Type)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case *ast.Ident:
+		if param, ok := context.typeParams[t.Name]; ok {
+			return l.isComparableConstraint(param.context, param.Constraint)
+		}
+		predeclared, err := l.isPredeclared(context, t.Name)
+		if err != nil {
+			return false, err
+		}
+		if predeclared {
+			return t.Name == "comparable", nil
+		}
+		discovery, err := l.FindIdentType(context, t)
+		if err != nil {
+			return false, err
+		}
+		return l.isComparableTerm(discovery)
+	case *ast.SelectorExpr:
+		if _, ok := t.X.(*ast.Ident); !ok {
+			return false, nil
+		}
+		discovery, err := l.FindSelectorType(context, t)
+		if err != nil {
+			return false, err
+		}
+		return l.isComparableTerm(discovery)
+	}
+	return l.IsComparable(context, constraint)
+}
+
+// isComparableTerm returns whether the discovered type, used as a term of a
+// constraint, admits comparable types only. Interfaces are constraints in
+// turn, while other types admit themselves.
+func (l *Locator) isComparableTerm(d TypeDiscovery) (bool, error) {
+	context := NewASTFileLocatorContext(d.File, d.Location)
+	if _, ok := d.Spec.Type.(*ast.InterfaceType); ok {
+		context.DeclareTypeParams(d.Spec.TypeParams)
+		return l.isComparableConstraint(context, d.Spec.Type)
+	}
+	return l.IsComparable(context, d.Spec.Type)
+}
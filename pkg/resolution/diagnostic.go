@@ -0,0 +1,139 @@
+package resolution
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DeclarationError is an error found in a declaration of the processed
+// source code, e.g. a reference to a type which cannot be found. It is
+// formatted like a compiler diagnostic, so that editors can jump to the
+// declaration, e.g.
+//   svc/service.go:12:24: interface 'Service': method 'Get': Could not find 'User' type.
+type DeclarationError struct {
+	// Pos is the position of the offending declaration, or of the innermost
+	// declaration being processed, if unknown.
+	Pos token.Position
+	// Decls describes the declarations being processed, outermost first,
+	// e.g. interface 'Service' and method 'Get'.
+	Decls []string
+	// Imports is the chain of packages through which the offending
+	// declaration was reached, starting with the package of the processed
+	// type, e.g. via interfaces embedded from other packages.
+	Imports []string
+	// Err is the error found in the declaration.
+	Err error
+}
+
+func (e *DeclarationError) Error() string {
+	var b strings.Builder
+	if e.Pos.IsValid() {
+		pos := e.Pos
+		pos.Filename = relativePath(pos.Filename)
+		fmt.Fprintf(&b, "%s: ", pos)
+	}
+	for _, decl := range e.Decls {
+		fmt.Fprintf(&b, "%s: ", decl)
+	}
+	b.WriteString(e.Err.Error())
+	if len(e.Imports) > 1 {
+		fmt.Fprintf(&b, " (import chain: %s)", strings.Join(e.Imports, " -> "))
+	}
+	return b.String()
+}
+
+func (e *DeclarationError) Unwrap() error {
+	return e.Err
+}
+
+// Position returns the position of a node parsed by the locator, e.g. the
+// name of a discovered method.
+func (l *Locator) Position(pos token.Pos) token.Position {
+	return l.fset.Position(pos)
+}
+
+// TypeError annotates the error found while processing the discovered type
+// with its declaration.
+func (l *Locator) TypeError(d TypeDiscovery, err error) error {
+	kind := "type"
+	switch d.Spec.Type.(type) {
+	case *ast.InterfaceType:
+		kind = "interface"
+	case *ast.StructType:
+		kind = "struct"
+	}
+	decl := fmt.Sprintf("%s '%s'", kind, d.Spec.Name.String())
+	return l.declarationError(decl, d.Spec.Name.Pos(), []string{d.Location}, err)
+}
+
+// MethodError annotates the error found while processing the discovered
+// method with its declaration.
+func (l *Locator) MethodError(m MethodDiscovery, err error) error {
+	decl := fmt.Sprintf("method '%s'", m.Name)
+	return l.declarationError(decl, m.Pos, m.imports, err)
+}
+
+// ErrorAt annotates the error with the position of the node, e.g. the doc
+// comment holding an invalid annotation, unless its position is known
+// already.
+func (l *Locator) ErrorAt(node ast.Node, err error) error {
+	return l.errorAt(node.Pos(), nil, err)
+}
+
+func (l *Locator) declarationError(decl string, pos token.Pos, imports []string, err error) error {
+	if err == nil {
+		return nil
+	}
+	declErr := l.errorAt(pos, imports, err).(*DeclarationError)
+	declErr.Decls = append([]string{decl}, declErr.Decls...)
+	return declErr
+}
+
+// errorAt annotates the error with the position and the chain of imports
+// through which the offending declaration was reached, unless they are
+// known already, as the innermost ones are the most precise.
+func (l *Locator) errorAt(pos token.Pos, imports []string, err error) error {
+	if err == nil {
+		return nil
+	}
+	declErr, ok := err.(*DeclarationError)
+	if !ok {
+		declErr = &DeclarationError{Err: err}
+	}
+	if !declErr.Pos.IsValid() && pos.IsValid() {
+		declErr.Pos = l.fset.Position(pos)
+	}
+	if declErr.Imports == nil {
+		declErr.Imports = imports
+	}
+	return declErr
+}
+
+// importChain returns the chain of imports extended by the location, unless
+// it is the last location of the chain already.
+func importChain(imports []string, location string) []string {
+	if len(imports) > 0 && imports[len(imports)-1] == location {
+		return imports
+	}
+	chain := make([]string, len(imports), len(imports)+1)
+	copy(chain, imports)
+	return append(chain, location)
+}
+
+// relativePath returns the path relative to the working directory, like
+// the compiler reports it, if the file is found beneath it.
+func relativePath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,121 @@
+package resolution
+
+import (
+	"go/ast"
+)
+
+// IsNilableError returns whether values of the specified type, as seen in
+// the specified context, implement the built-in error interface and can be
+// compared to nil. These are the error interface itself, interfaces which
+// include an Error() string method and pointers to types with such a method.
+func (l *Locator) IsNilableError(context *LocatorContext, astType ast.Expr) (bool, error) {
+	pointer := false
+	if star, ok := astType.(*ast.StarExpr); ok {
+		pointer = true
+		astType = star.X
+	}
+
+	var discovery TypeDiscovery
+	var err error
+	switch t := astType.(type) {
+	case *ast.Ident:
+		if _, ok := context.TypeParam(t.Name); ok {
+			return false, nil
+		}
+		var predeclared bool
+		predeclared, err = l.isPredeclared(context, t.Name)
+		if err != nil {
+			return false, err
+		}
+		if predeclared {
+			return t.Name == "error" && !pointer, nil
+		}
+		discovery, err = l.FindIdentType(context, t)
+	case *ast.SelectorExpr:
+		if _, ok := t.X.(*ast.Ident); !ok {
+			return false, nil
+		}
+		discovery, err = l.FindSelectorType(context, t)
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return l.implementsError(discovery, pointer)
+}
+
+func (l *Locator) implementsError(d TypeDiscovery, pointer bool) (bool, error) {
+	switch t := d.Spec.Type.(type) {
+	case *ast.InterfaceType:
+		// Pointers to interfaces have no methods.
+		if pointer {
+			return false, nil
+		}
+		return l.interfaceImplementsError(d, t)
+	case *ast.Ident, *ast.SelectorExpr:
+		// Defined types and aliases of interface types share their method
+		// sets.
+		if pointer && d.Spec.Assign == 0 {
+			break
+		}
+		context := NewASTFileLocatorContext(d.File, d.Location)
+		if pointer {
+			return l.IsNilableError(context, &ast.StarExpr{X: t})
+		}
+		return l.IsNilableError(context, t)
+	}
+
+	// Values of non-interface types cannot be nil.
+	if !pointer {
+		return false, nil
+	}
+	methods, err := l.findMethodDeclarations(d.Spec.Name.Name, d.Location)
+	if err != nil {
+		return false, err
+	}
+	for _, method := range methods {
+		if method.Name.Name == "Error" && isErrorMethodType(method.Type) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (l *Locator) interfaceImplementsError(d TypeDiscovery, iface *ast.InterfaceType) (bool, error) {
+	context := NewASTFileLocatorContext(d.File, d.Location)
+	for _, field := range iface.Methods.List {
+		switch t := field.Type.(type) {
+		case *ast.FuncType:
+			if field.Names[0].Name == "Error" && isErrorMethodType(t) {
+				return true, nil
+			}
+		case *ast.Ident, *ast.SelectorExpr:
+			ok, err := l.IsNilableError(context, t)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// isErrorMethodType returns whether funcType matches the signature of the
+// Error method of the built-in error interface.
+func isErrorMethodType(funcType *ast.FuncType) bool {
+	if funcType.Params != nil && len(funcType.Params.List) > 0 {
+		return false
+	}
+	if funcType.Results == nil || len(funcType.Results.List) != 1 {
+		return false
+	}
+	result := funcType.Results.List[0]
+	if len(result.Names) > 1 {
+		return false
+	}
+	id, ok := result.Type.(*ast.Ident)
+	return ok && id.Name == "string"
+}
@@ -0,0 +1,86 @@
+package resolution
+
+import (
+	"go/ast"
+	"go/parser"
+	"testing"
+)
+
+const testdataPath = "github.com/Bo0mer/gentools/pkg/resolution/testdata"
+
+// fileContext returns the context of the file declaring the named type in
+// the package in the location.
+func fileContext(t *testing.T, l *Locator, location, name string) *LocatorContext {
+	t.Helper()
+	d, err := l.FindIdentType(NewSingleLocationContext(location), ast.NewIdent(name))
+	if err != nil {
+		t.Fatalf("error finding %s in %s: %v", name, location, err)
+	}
+	return NewASTFileLocatorContext(d.File, d.Location)
+}
+
+func TestIsNilableError(t *testing.T) {
+	tests := []struct {
+		typ  string
+		want bool
+	}{
+		{"error", true},
+		{"Coded", true},
+		{"MyError", true},
+		{"Wrapped", true},
+		{"Remote", true},
+		{"failures.Failure", true},
+		{"Named", true},
+		{"Alias", true},
+		{"*Impl", true},
+		{"*ValueImpl", true},
+		{"*ImplAlias", true},
+		{"*failures.Err", true},
+
+		{"Impl", false},
+		{"ValueImpl", false},
+		{"failures.Err", false},
+		{"*error", false},
+		{"*MyError", false},
+		{"*Named", false},
+		{"Stringer", false},
+		{"BadError", false},
+		{"Code", false},
+		{"*Code", false},
+		{"Plain", false},
+		{"*Plain", false},
+		{"string", false},
+		{"bool", false},
+		{"[]error", false},
+		{"map[string]error", false},
+		{"chan error", false},
+		{"func() error", false},
+		{"struct{ error }", false},
+	}
+
+	l := NewLocator()
+	context := fileContext(t, l, testdataPath+"/errs", "Coded")
+	for _, test := range tests {
+		t.Run(test.typ, func(t *testing.T) {
+			typ, err := parser.ParseExpr(test.typ)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := l.IsNilableError(context, typ)
+			if err != nil {
+				t.Fatalf("IsNilableError() error = %v", err)
+			}
+			if got != test.want {
+				t.Errorf("IsNilableError() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestIsNilableErrorUnknownType(t *testing.T) {
+	l := NewLocator()
+	context := fileContext(t, l, testdataPath+"/errs", "Coded")
+	if _, err := l.IsNilableError(context, ast.NewIdent("Missing")); err == nil {
+		t.Fatal("IsNilableError() of unknown type returned no error")
+	}
+}
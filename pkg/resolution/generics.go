@@ -0,0 +1,150 @@
+package resolution
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+)
+
+// instantiation is an instantiated generic type, e.g. Store[User], split
+// into the generic type and its type arguments.
+func instantiation(expr ast.Expr) (ast.Expr, []ast.Expr, bool) {
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		return t.X, []ast.Expr{t.Index}, true
+	case *ast.IndexListExpr:
+		return t.X, t.Indices, true
+	}
+	return nil, nil, false
+}
+
+// typeArguments returns the type arguments of the instantiation of the
+// discovered generic type, keyed by the names of its type parameters.
+//
+// The type arguments are seen in the context of the instantiation, while
+// the types they are substituted into are seen in the context of the
+// generic type. Therefore, the named types in the arguments are qualified by
+// aliases, which are unique to the arguments and bound to the locations of
+// their packages by the returned imports, to be added to the context of the
+// generic type.
+func (l *Locator) typeArguments(context *LocatorContext, d TypeDiscovery, args []ast.Expr) (map[string]ast.Expr, []importEntry, error) {
+	var params []*ast.Ident
+	if d.Spec.TypeParams != nil {
+		for _, field := range d.Spec.TypeParams.List {
+			params = append(params, field.Names...)
+		}
+	}
+	if len(params) != len(args) {
+		return nil, nil, fmt.Errorf("type '%s' in '%s' expects %d type arguments, got %d", d.Spec.Name.String(), d.Location, len(params), len(args))
+	}
+
+	binder := &typeArgumentBinder{}
+	// The type arguments are bound rather than imported, so their
+	// visibility is checked once they are resolved in the signatures.
+	resolver := NewResolver(binder, l, "")
+	typeArgs := map[string]ast.Expr{}
+	for i, arg := range args {
+		bound, err := resolver.ResolveType(context, arg)
+		if err != nil {
+			return nil, nil, err
+		}
+		typeArgs[params[i].Name] = bound
+	}
+	return typeArgs, binder.imports, nil
+}
+
+// typeArgumentBinder allocates the aliases of the packages referred to by
+// type arguments. The aliases cannot clash with the imports of any file, as
+// they are not valid identifiers.
+type typeArgumentBinder struct {
+	imports []importEntry
+}
+
+func (b *typeArgumentBinder) AddImport(pkgName, location string) string {
+	for _, imp := range b.imports {
+		if imp.Location == location {
+			return imp.Alias
+		}
+	}
+	alias := fmt.Sprintf("typearg·%d", len(b.imports)+1)
+	b.imports = append(b.imports, importEntry{Alias: alias, Location: location})
+	return alias
+}
+
+// substitute returns a copy of the type in which the type parameters are
+// replaced by the type arguments.
+func substitute(astType ast.Expr, typeArgs map[string]ast.Expr) ast.Expr {
+	if len(typeArgs) == 0 || astType == nil {
+		return astType
+	}
+	switch t := astType.(type) {
+	case *ast.Ident:
+		if arg, ok := typeArgs[t.Name]; ok {
+			return arg
+		}
+		return t
+	case *ast.ParenExpr:
+		return &ast.ParenExpr{X: substitute(t.X, typeArgs)}
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: substitute(t.X, typeArgs)}
+	case *ast.Ellipsis:
+		return &ast.Ellipsis{Elt: substitute(t.Elt, typeArgs)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: t.Len, Elt: substitute(t.Elt, typeArgs)}
+	case *ast.MapType:
+		return &ast.MapType{Key: substitute(t.Key, typeArgs), Value: substitute(t.Value, typeArgs)}
+	case *ast.ChanType:
+		return &ast.ChanType{Dir: t.Dir, Value: substitute(t.Value, typeArgs)}
+	case *ast.FuncType:
+		return &ast.FuncType{
+			Params:  substituteFieldList(t.Params, typeArgs),
+			Results: substituteFieldList(t.Results, typeArgs),
+		}
+	case *ast.StructType:
+		return &ast.StructType{Fields: substituteFieldList(t.Fields, typeArgs)}
+	case *ast.InterfaceType:
+		return &ast.InterfaceType{Methods: substituteFieldList(t.Methods, typeArgs)}
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: t.X, Index: substitute(t.Index, typeArgs)}
+	case *ast.IndexListExpr:
+		indices := make([]ast.Expr, len(t.Indices))
+		for i, index := range t.Indices {
+			indices[i] = substitute(index, typeArgs)
+		}
+		return &ast.IndexListExpr{X: t.X, Indices: indices}
+	case *ast.BinaryExpr:
+		return &ast.BinaryExpr{X: substitute(t.X, typeArgs), Op: t.Op, Y: substitute(t.Y, typeArgs)}
+	case *ast.UnaryExpr:
+		return &ast.UnaryExpr{Op: t.Op, X: substitute(t.X, typeArgs)}
+	}
+	return astType
+}
+
+func substituteFieldList(fieldList *ast.FieldList, typeArgs map[string]ast.Expr) *ast.FieldList {
+	if fieldList == nil {
+		return nil
+	}
+	substituted := &ast.FieldList{List: make([]*ast.Field, len(fieldList.List))}
+	for i, field := range fieldList.List {
+		f := *field
+		f.Type = substitute(field.Type, typeArgs)
+		substituted.List[i] = &f
+	}
+	return substituted
+}
+
+// isTypeConstraint returns whether the element of an interface restricts its
+// type set, e.g. ~int | ~string, making it usable as a constraint only.
+func isTypeConstraint(expr ast.Expr) bool {
+	switch expr.(type) {
+	case *ast.BinaryExpr, *ast.UnaryExpr:
+		return true
+	}
+	return false
+}
+
+// constraintError reports an interface which cannot be implemented, as it
+// can be used as a constraint only.
+func constraintError(location string, expr ast.Expr) error {
+	return &ConstraintError{Location: location, Constraint: types.ExprString(expr)}
+}
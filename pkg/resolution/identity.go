@@ -0,0 +1,148 @@
+package resolution
+
+import (
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// IdenticalSignatures returns whether two method signatures, each as seen in
+// its own context, are identical, i.e. whether their parameters and results
+// have identical types, regardless of their names.
+func (l *Locator) IdenticalSignatures(context1 *LocatorContext, sig1 *ast.FuncType, context2 *LocatorContext, sig2 *ast.FuncType) (bool, error) {
+	s1, err := l.canonicalType(context1, sig1)
+	if err != nil {
+		return false, err
+	}
+	s2, err := l.canonicalType(context2, sig2)
+	if err != nil {
+		return false, err
+	}
+	return s1 == s2, nil
+}
+
+// canonicalType returns the representation of the type, as seen in the
+// specified context, in which named types are qualified by the locations of
+// their packages, so that identical types are represented identically
+// regardless of how their packages are imported.
+func (l *Locator) canonicalType(context *LocatorContext, astType ast.Expr) (string, error) {
+	switch t := astType.(type) {
+	case *ast.Ident:
+		if _, ok := context.TypeParam(t.Name); ok {
+			return t.Name, nil
+		}
+		predeclared, err := l.isPredeclared(context, t.Name)
+		if err != nil {
+			return "", err
+		}
+		if predeclared {
+			return t.Name, nil
+		}
+		discovery, err := l.FindIdentType(context, t)
+		if err != nil {
+			return "", err
+		}
+		return discovery.Location + "." + t.Name, nil
+	case *ast.SelectorExpr:
+		if _, ok := t.X.(*ast.Ident); !ok {
+			return types.ExprString(t), nil
+		}
+		discovery, err := l.FindSelectorType(context, t)
+		if err != nil {
+			return "", err
+		}
+		return discovery.Location + "." + t.Sel.Name, nil
+	case *ast.ParenExpr:
+		return l.canonicalType(context, t.X)
+	case *ast.StarExpr:
+		x, err := l.canonicalType(context, t.X)
+		return "*" + x, err
+	case *ast.Ellipsis:
+		elt, err := l.canonicalType(context, t.Elt)
+		return "..." + elt, err
+	case *ast.ArrayType:
+		elt, err := l.canonicalType(context, t.Elt)
+		if t.Len == nil {
+			return "[]" + elt, err
+		}
+		return "[" + types.ExprString(t.Len) + "]" + elt, err
+	case *ast.MapType:
+		key, err := l.canonicalType(context, t.Key)
+		if err != nil {
+			return "", err
+		}
+		value, err := l.canonicalType(context, t.Value)
+		return "map[" + key + "]" + value, err
+	case *ast.ChanType:
+		value, err := l.canonicalType(context, t.Value)
+		switch t.Dir {
+		case ast.SEND:
+			return "chan<- " + value, err
+		case ast.RECV:
+			return "<-chan " + value, err
+		}
+		return "chan " + value, err
+	case *ast.FuncType:
+		params, err := l.canonicalFieldList(context, t.Params, false)
+		if err != nil {
+			return "", err
+		}
+		results, err := l.canonicalFieldList(context, t.Results, false)
+		return "func(" + strings.Join(params, ", ") + ") (" + strings.Join(results, ", ") + ")", err
+	case *ast.StructType:
+		fields, err := l.canonicalFieldList(context, t.Fields, true)
+		return "struct{" + strings.Join(fields, "; ") + "}", err
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		generic, args, _ := instantiation(t)
+		x, err := l.canonicalType(context, generic)
+		if err != nil {
+			return "", err
+		}
+		canonicalArgs := make([]string, len(args))
+		for i, arg := range args {
+			canonicalArgs[i], err = l.canonicalType(context, arg)
+			if err != nil {
+				return "", err
+			}
+		}
+		return x + "[" + strings.Join(canonicalArgs, ", ") + "]", nil
+	case *ast.InterfaceType:
+		// The order of the methods of an interface does not matter.
+		methods, err := l.canonicalFieldList(context, t.Methods, true)
+		sort.Strings(methods)
+		return "interface{" + strings.Join(methods, "; ") + "}", err
+	}
+	return types.ExprString(astType), nil
+}
+
+// canonicalFieldList returns the canonical types of the fields in the list,
+// one for each name, prefixed by the names and followed by the tags if
+// requested.
+func (l *Locator) canonicalFieldList(context *LocatorContext, fieldList *ast.FieldList, named bool) ([]string, error) {
+	if fieldList == nil {
+		return nil, nil
+	}
+	var result []string
+	for _, field := range fieldList.List {
+		fieldType, err := l.canonicalType(context, field.Type)
+		if err != nil {
+			return nil, err
+		}
+		if named && field.Tag != nil {
+			fieldType += " " + field.Tag.Value
+		}
+		if len(field.Names) == 0 {
+			result = append(result, fieldType)
+			continue
+		}
+		for _, name := range field.Names {
+			if named {
+				result = append(result, name.Name+" "+fieldType)
+			} else {
+				result = append(result, fieldType)
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,65 @@
+package resolution
+
+import (
+	"errors"
+	"go/ast"
+	"sort"
+)
+
+// FindInterfaceTypes returns the exported interface types declared in the
+// specified location, except in test files, sorted by name.
+func (l *Locator) FindInterfaceTypes(location string) ([]TypeDiscovery, error) {
+	discoveries, err := l.discoverTypes(location)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []TypeDiscovery{}
+	for _, d := range discoveries {
+		if _, ok := d.Spec.Type.(*ast.InterfaceType); !ok || !d.Spec.Name.IsExported() {
+			continue
+		}
+		result = append(result, d)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Spec.Name.String() < result[j].Spec.Name.String()
+	})
+	return result, nil
+}
+
+// IsImplementable returns whether the discovered interface can be
+// implemented by other packages, i.e. whether all of its methods, including
+// the ones of the embedded interfaces, are exported, so that it is not
+// sealed, and it is not restricted by type constraints.
+func (l *Locator) IsImplementable(d TypeDiscovery) (bool, error) {
+	methods, err := l.FindInterfaceMethods(d)
+	var constraintErr *ConstraintError
+	if errors.As(err, &constraintErr) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, method := range methods {
+		if !ast.IsExported(method.Name) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Doc returns the doc comment of the discovered type. The doc comment of a
+// type declared on its own is attached to its declaration rather than to its
+// specification.
+func (d TypeDiscovery) Doc() *ast.CommentGroup {
+	if d.Spec.Doc != nil {
+		return d.Spec.Doc
+	}
+	for _, decl := range d.File.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if ok && len(genDecl.Specs) == 1 && genDecl.Specs[0] == d.Spec {
+			return genDecl.Doc
+		}
+	}
+	return nil
+}
@@ -0,0 +1,380 @@
+package resolution
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"github.com/Bo0mer/gentools/pkg/internal"
+)
+
+// MethodDiscovery describes a method found in the method set of a type.
+type MethodDiscovery struct {
+	// Location is the location of the package declaring the method.
+	Location string
+	// File is the file declaring the method, in which its type is resolved.
+	File *ast.File
+	// Name is the name of the method.
+	Name string
+	// Pos is the position of the name of the method.
+	Pos token.Pos
+	// Doc is the doc comment of the method.
+	Doc *ast.CommentGroup
+	// Type is the signature of the method.
+	Type *ast.FuncType
+
+	// bindings are the imports of the type arguments substituted into the
+	// signature of the method.
+	bindings []importEntry
+	// typeParams are the type parameters in scope of the signature of the
+	// method, if it is declared by a generic interface.
+	typeParams map[string]typeParam
+	// imports is the chain of packages through which the method was
+	// reached.
+	imports []string
+}
+
+// parsePredeclaredTypes returns the declarations of the predeclared
+// interfaces, whose methods are promoted when they are embedded.
+func parsePredeclaredTypes(fset *token.FileSet) map[string]TypeDiscovery {
+	src := "package builtin\n\ntype error interface{ Error() string }\n\ntype any = interface{}\n"
+	file, err := parser.ParseFile(fset, "builtin.go", src, 0)
+	if err != nil {
+		panic(err)
+	}
+	discoveries := map[string]TypeDiscovery{}
+	for spec := range internal.EachTypeSpecificationInFile(file) {
+		discoveries[spec.Name.Name] = TypeDiscovery{
+			File: file,
+			Spec: spec,
+		}
+	}
+	return discoveries
+}
+
+// FindMethodSet returns the exported methods of a pointer to the discovered
+// struct type, sorted by name. Methods promoted from embedded fields are
+// included, unless they are shadowed by a field or method of a shallower
+// depth, or are ambiguous, i.e. promoted from several fields of the same
+// depth.
+func (l *Locator) FindMethodSet(d TypeDiscovery) ([]MethodDiscovery, error) {
+	if _, ok := d.Spec.Type.(*ast.StructType); !ok {
+		return nil, l.errorAt(d.Spec.Name.Pos(), nil, fmt.Errorf("type '%s' in '%s' is not struct!", d.Spec.Name.String(), d.Location))
+	}
+
+	result := []MethodDiscovery{}
+	selected := map[string]bool{}
+	visited := map[string]bool{}
+	imports := map[string][]string{typeKey(d): {d.Location}}
+	level := []TypeDiscovery{d}
+	for len(level) > 0 {
+		candidates := map[string][]MethodDiscovery{}
+		fields := map[string]bool{}
+		var next []TypeDiscovery
+		for _, t := range level {
+			key := typeKey(t)
+			if visited[key] {
+				continue
+			}
+			visited[key] = true
+
+			methods, embedded, err := l.typeMembers(t, imports[key], fields)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range methods {
+				candidates[m.Name] = append(candidates[m.Name], m)
+			}
+			for _, e := range embedded {
+				if _, ok := imports[typeKey(e)]; !ok {
+					imports[typeKey(e)] = importChain(imports[key], e.Location)
+				}
+			}
+			next = append(next, embedded...)
+		}
+
+		for name, methods := range candidates {
+			if selected[name] {
+				continue
+			}
+			if len(methods) == 1 && !fields[name] && ast.IsExported(name) {
+				result = append(result, methods[0])
+			}
+		}
+		// Members of this depth, even ambiguous ones, shadow the members of
+		// the embedded types.
+		for name := range candidates {
+			selected[name] = true
+		}
+		for name := range fields {
+			selected[name] = true
+		}
+		level = next
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+func typeKey(d TypeDiscovery) string {
+	return d.Location + "." + d.Spec.Name.String()
+}
+
+// typeMembers returns the methods of the discovered type, reached through
+// the chain of imports, and the types of its embedded fields, and records
+// the names of its fields.
+func (l *Locator) typeMembers(d TypeDiscovery, imports []string, fields map[string]bool) ([]MethodDiscovery, []TypeDiscovery, error) {
+	context := NewASTFileLocatorContext(d.File, d.Location)
+	switch t := d.Spec.Type.(type) {
+	case *ast.InterfaceType:
+		methods, err := l.findInterfaceMethods(d, imports)
+		return methods, nil, err
+	case *ast.Ident, *ast.SelectorExpr:
+		// Aliases share the method set of the aliased type.
+		if d.Spec.Assign != 0 {
+			aliased, ok, err := l.embeddedType(context, t)
+			if err != nil || !ok {
+				return nil, nil, l.errorAt(t.Pos(), imports, err)
+			}
+			return l.typeMembers(aliased, importChain(imports, aliased.Location), fields)
+		}
+	}
+
+	declarations, err := l.findMethodDeclarations(d.Spec.Name.Name, d.Location)
+	if err != nil {
+		return nil, nil, l.errorAt(d.Spec.Name.Pos(), imports, err)
+	}
+	var methods []MethodDiscovery
+	for _, decl := range declarations {
+		methods = append(methods, MethodDiscovery{
+			Location: d.Location,
+			File:     decl.File,
+			Name:     decl.Name.Name,
+			Pos:      decl.Name.Pos(),
+			Doc:      decl.Doc,
+			Type:     decl.Type,
+			imports:  imports,
+		})
+	}
+
+	strct, ok := d.Spec.Type.(*ast.StructType)
+	if !ok {
+		return methods, nil, nil
+	}
+	var embedded []TypeDiscovery
+	for _, field := range strct.Fields.List {
+		for _, name := range field.Names {
+			fields[name.Name] = true
+		}
+		if len(field.Names) > 0 {
+			continue
+		}
+		fieldType := field.Type
+		if star, ok := fieldType.(*ast.StarExpr); ok {
+			fieldType = star.X
+		}
+		switch t := fieldType.(type) {
+		case *ast.Ident:
+			fields[t.Name] = true
+		case *ast.SelectorExpr:
+			fields[t.Sel.Name] = true
+		}
+		e, ok, err := l.embeddedType(context, fieldType)
+		if err != nil {
+			return nil, nil, l.errorAt(field.Pos(), imports, err)
+		}
+		if ok {
+			embedded = append(embedded, e)
+		}
+	}
+	return methods, embedded, nil
+}
+
+// FindInterfaceMethods returns the methods of the discovered interface type,
+// including the methods of the embedded interfaces, in the order in which
+// they are declared. Methods declared by several embedded interfaces, which
+// must have identical signatures, are returned only once.
+func (l *Locator) FindInterfaceMethods(d TypeDiscovery) ([]MethodDiscovery, error) {
+	return l.findInterfaceMethods(d, []string{d.Location})
+}
+
+// findInterfaceMethods returns the methods of the discovered interface type,
+// reached through the chain of imports.
+func (l *Locator) findInterfaceMethods(d TypeDiscovery, imports []string) ([]MethodDiscovery, error) {
+	iface, ok := d.Spec.Type.(*ast.InterfaceType)
+	if !ok {
+		return nil, l.errorAt(d.Spec.Name.Pos(), imports, fmt.Errorf("type '%s' in '%s' is not interface!", d.Spec.Name.String(), d.Location))
+	}
+	scope := methodScope{location: d.Location, file: d.File, imports: imports}
+	if d.Spec.TypeParams != nil {
+		context := NewASTFileLocatorContext(d.File, d.Location)
+		context.DeclareTypeParams(d.Spec.TypeParams)
+		scope.typeParams = context.typeParams
+	}
+	methods, err := l.interfaceMethods(scope, iface)
+	if err != nil {
+		return nil, err
+	}
+
+	var unique []MethodDiscovery
+	declared := map[string]MethodDiscovery{}
+	for _, method := range methods {
+		first, ok := declared[method.Name]
+		if !ok {
+			declared[method.Name] = method
+			unique = append(unique, method)
+			continue
+		}
+		identical, err := l.IdenticalSignatures(first.Context(), first.Type, method.Context(), method.Type)
+		if err != nil {
+			return nil, l.errorAt(method.Pos, method.imports, err)
+		}
+		if !identical {
+			return nil, l.errorAt(method.Pos, method.imports, &DuplicateMethodError{
+				Name:       method.Name,
+				Signature1: types.ExprString(first.Type),
+				Signature2: types.ExprString(method.Type),
+			})
+		}
+	}
+	return unique, nil
+}
+
+// methodScope is the scope of the declarations of the methods of an
+// interface, i.e. the file declaring them along with the type arguments of
+// the instantiation of the interface, if it is generic. The type parameters
+// of the implemented interface, if it is generic itself, remain in scope, as
+// they may be passed as type arguments. The scope is reached through the
+// chain of imports.
+type methodScope struct {
+	location   string
+	file       *ast.File
+	typeArgs   map[string]ast.Expr
+	bindings   []importEntry
+	typeParams map[string]typeParam
+	imports    []string
+}
+
+func (s methodScope) context() *LocatorContext {
+	context := NewASTFileLocatorContext(s.file, s.location)
+	context.imports = append(context.imports, s.bindings...)
+	context.typeParams = s.typeParams
+	return context
+}
+
+// interfaceMethods returns the methods of the interface type, including the
+// methods of the embedded interfaces, with the type arguments of the scope
+// substituted into their signatures.
+func (l *Locator) interfaceMethods(scope methodScope, iface *ast.InterfaceType) ([]MethodDiscovery, error) {
+	context := scope.context()
+	var methods []MethodDiscovery
+	for _, field := range iface.Methods.List {
+		embedded := substitute(field.Type, scope.typeArgs)
+		switch t := embedded.(type) {
+		case *ast.FuncType:
+			methods = append(methods, MethodDiscovery{
+				Location:   scope.location,
+				File:       scope.file,
+				Name:       field.Names[0].Name,
+				Pos:        field.Names[0].Pos(),
+				Doc:        field.Doc,
+				Type:       t,
+				bindings:   scope.bindings,
+				typeParams: scope.typeParams,
+				imports:    scope.imports,
+			})
+			continue
+		case *ast.BinaryExpr, *ast.UnaryExpr:
+			return nil, l.errorAt(field.Pos(), scope.imports, constraintError(scope.location, t))
+		}
+
+		var args []ast.Expr
+		if generic, typeArgs, ok := instantiation(embedded); ok {
+			embedded, args = generic, typeArgs
+		}
+		switch embedded.(type) {
+		case *ast.Ident, *ast.SelectorExpr:
+		default:
+			return nil, l.errorAt(field.Pos(), scope.imports, errors.New("Unknown statement in interface declaration."))
+		}
+		if id, ok := embedded.(*ast.Ident); ok && id.Name == "comparable" {
+			predeclared, err := l.isPredeclared(context, id.Name)
+			if err != nil {
+				return nil, l.errorAt(field.Pos(), scope.imports, err)
+			}
+			if predeclared {
+				return nil, l.errorAt(field.Pos(), scope.imports, constraintError(scope.location, id))
+			}
+		}
+		e, ok, err := l.embeddedType(context, embedded)
+		if err != nil {
+			return nil, l.errorAt(field.Pos(), scope.imports, err)
+		}
+		var embeddedIface *ast.InterfaceType
+		if ok {
+			embeddedIface, ok = e.Spec.Type.(*ast.InterfaceType)
+		}
+		if !ok {
+			// Embedded types other than interfaces are type terms.
+			return nil, l.errorAt(field.Pos(), scope.imports, constraintError(scope.location, field.Type))
+		}
+
+		embeddedScope := methodScope{
+			location:   e.Location,
+			file:       e.File,
+			typeParams: scope.typeParams,
+			imports:    importChain(scope.imports, e.Location),
+		}
+		if len(args) > 0 || e.Spec.TypeParams != nil {
+			embeddedScope.typeArgs, embeddedScope.bindings, err = l.typeArguments(context, e, args)
+			if err != nil {
+				return nil, l.errorAt(field.Pos(), scope.imports, err)
+			}
+		}
+		embeddedMethods, err := l.interfaceMethods(embeddedScope, embeddedIface)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, embeddedMethods...)
+	}
+	return methods, nil
+}
+
+// Context returns the context in which the type of the method is resolved.
+func (m MethodDiscovery) Context() *LocatorContext {
+	return methodScope{location: m.Location, file: m.File, bindings: m.bindings, typeParams: m.typeParams}.context()
+}
+
+// embeddedType returns the declaration of the embedded type, or false if it
+// has no declaration, e.g. int or a type parameter.
+func (l *Locator) embeddedType(context *LocatorContext, astType ast.Expr) (TypeDiscovery, bool, error) {
+	switch t := astType.(type) {
+	case *ast.Ident:
+		if _, ok := context.TypeParam(t.Name); ok {
+			return TypeDiscovery{}, false, nil
+		}
+		predeclared, err := l.isPredeclared(context, t.Name)
+		if err != nil {
+			return TypeDiscovery{}, false, err
+		}
+		if predeclared {
+			d, ok := l.predeclared[t.Name]
+			return d, ok, nil
+		}
+		d, err := l.FindIdentType(context, t)
+		return d, err == nil, err
+	case *ast.SelectorExpr:
+		if _, ok := t.X.(*ast.Ident); !ok {
+			return TypeDiscovery{}, false, nil
+		}
+		d, err := l.FindSelectorType(context, t)
+		return d, err == nil, err
+	}
+	return TypeDiscovery{}, false, nil
+}
@@ -0,0 +1,91 @@
+package resolution
+
+import (
+	"errors"
+	"go/ast"
+	"path"
+	"reflect"
+	"testing"
+)
+
+// interfaceMethods returns the flattened methods of the named interface in
+// the embeds test package.
+func interfaceMethods(t *testing.T, name string) ([]MethodDiscovery, error) {
+	t.Helper()
+	l := NewLocator()
+	d, err := l.FindIdentType(NewSingleLocationContext(testdataPath+"/embeds"), ast.NewIdent(name))
+	if err != nil {
+		t.Fatalf("error finding %s: %v", name, err)
+	}
+	return l.FindInterfaceMethods(d)
+}
+
+func TestFindInterfaceMethods(t *testing.T) {
+	tests := []struct {
+		name string
+		// want are the methods, qualified by the name of the package
+		// declaring them.
+		want []string
+	}{
+		{"Plain", []string{"embeds.Open", "embeds.Close"}},
+		{"Overlapping", []string{"embeds.Write", "embeds.Close", "embeds.Flush"}},
+		{"Redeclared", []string{"embeds.Close"}},
+		{"Nested", []string{"embeds.Write", "embeds.Close", "embeds.Flush"}},
+		{"Remote", []string{"embeds.Get", "other.Put"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			methods, err := interfaceMethods(t, test.name)
+			if err != nil {
+				t.Fatalf("FindInterfaceMethods() error = %v", err)
+			}
+			var got []string
+			for _, method := range methods {
+				got = append(got, path.Base(method.Location)+"."+method.Name)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("FindInterfaceMethods() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFindInterfaceMethodsKeepsFirstDeclaration(t *testing.T) {
+	methods, err := interfaceMethods(t, "Remote")
+	if err != nil {
+		t.Fatalf("FindInterfaceMethods() error = %v", err)
+	}
+	if doc := methods[0].Doc.Text(); doc != "Get returns the item with the key.\n" {
+		t.Errorf("doc of Get = %q, want the doc of Getter.Get", doc)
+	}
+}
+
+func TestFindInterfaceMethodsConflicting(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+	}{
+		{"Conflicting", "Close"},
+		{"ConflictingRedeclared", "Close"},
+		{"ConflictingRemote", "Get"},
+		{"ConflictingNested", "Close"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := interfaceMethods(t, test.name)
+			var dupErr *DuplicateMethodError
+			if !errors.As(err, &dupErr) {
+				t.Fatalf("FindInterfaceMethods() error = %v, want DuplicateMethodError", err)
+			}
+			if dupErr.Name != test.method {
+				t.Errorf("duplicate method = %s, want %s", dupErr.Name, test.method)
+			}
+			var declErr *DeclarationError
+			if !errors.As(err, &declErr) || !declErr.Pos.IsValid() {
+				t.Errorf("FindInterfaceMethods() error = %v, want it reported at the method", err)
+			}
+		})
+	}
+}
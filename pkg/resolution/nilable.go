@@ -0,0 +1,81 @@
+package resolution
+
+import (
+	"go/ast"
+)
+
+// IsNilable returns whether values of the specified type, as seen in the
+// specified context, can be compared to nil, i.e. whether its underlying
+// type is a pointer, slice, map, channel, func or interface type. Type
+// parameters are not considered nilable, as they may be instantiated with
+// any type satisfying their constraints.
+func (l *Locator) IsNilable(context *LocatorContext, astType ast.Expr) (bool, error) {
+	switch t := astType.(type) {
+	case *ast.StarExpr, *ast.FuncType, *ast.MapType, *ast.ChanType, *ast.InterfaceType:
+		return true, nil
+	case *ast.ArrayType:
+		return t.Len == nil, nil
+	case *ast.ParenExpr:
+		return l.IsNilable(context, t.X)
+	case *ast.Ident, *ast.SelectorExpr, *ast.IndexExpr, *ast.IndexListExpr:
+		d, predeclared, ok, err := l.underlyingDeclaration(context, t)
+		if err != nil || !ok {
+			return false, err
+		}
+		if predeclared != "" {
+			return predeclared == "any" || predeclared == "error", nil
+		}
+		return l.IsNilable(NewASTFileLocatorContext(d.File, d.Location), d.Spec.Type)
+	}
+	return false, nil
+}
+
+// IsBool returns whether the underlying type of the specified type, as seen
+// in the specified context, is bool.
+func (l *Locator) IsBool(context *LocatorContext, astType ast.Expr) (bool, error) {
+	switch t := astType.(type) {
+	case *ast.ParenExpr:
+		return l.IsBool(context, t.X)
+	case *ast.Ident, *ast.SelectorExpr, *ast.IndexExpr, *ast.IndexListExpr:
+		d, predeclared, ok, err := l.underlyingDeclaration(context, t)
+		if err != nil || !ok {
+			return false, err
+		}
+		if predeclared != "" {
+			return predeclared == "bool", nil
+		}
+		return l.IsBool(NewASTFileLocatorContext(d.File, d.Location), d.Spec.Type)
+	}
+	return false, nil
+}
+
+// underlyingDeclaration returns the declaration of the named, possibly
+// instantiated, type, or the name of the predeclared type. It returns false
+// for type parameters and for qualified expressions that are not types.
+func (l *Locator) underlyingDeclaration(context *LocatorContext, astType ast.Expr) (TypeDiscovery, string, bool, error) {
+	if generic, _, ok := instantiation(astType); ok {
+		astType = generic
+	}
+	switch t := astType.(type) {
+	case *ast.Ident:
+		if _, ok := context.TypeParam(t.Name); ok {
+			return TypeDiscovery{}, "", false, nil
+		}
+		predeclared, err := l.isPredeclared(context, t.Name)
+		if err != nil {
+			return TypeDiscovery{}, "", false, err
+		}
+		if predeclared {
+			return TypeDiscovery{}, t.Name, true, nil
+		}
+		d, err := l.FindIdentType(context, t)
+		return d, "", err == nil, err
+	case *ast.SelectorExpr:
+		if _, ok := t.X.(*ast.Ident); !ok {
+			return TypeDiscovery{}, "", false, nil
+		}
+		d, err := l.FindSelectorType(context, t)
+		return d, "", err == nil, err
+	}
+	return TypeDiscovery{}, "", false, nil
+}
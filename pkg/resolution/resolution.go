@@ -5,19 +5,39 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/Bo0mer/gentools/pkg/internal"
 )
 
 func NewLocator() *Locator {
+	fset := token.NewFileSet()
 	return &Locator{
-		cache: make(map[string][]TypeDiscovery),
+		fset:        fset,
+		cache:       make(map[string][]TypeDiscovery),
+		methodCache: make(map[string][]methodDeclaration),
+		valueCache:  make(map[string]map[string]bool),
+		names:       make(map[string]string),
+		versions:    make(map[string]int),
+		predeclared: parsePredeclaredTypes(fset),
 	}
 }
 
 type Locator struct {
-	cache map[string][]TypeDiscovery
+	// fset holds the positions of all files parsed by the locator, which
+	// are reported by its errors.
+	fset        *token.FileSet
+	cache       map[string][]TypeDiscovery
+	methodCache map[string][]methodDeclaration
+	valueCache  map[string]map[string]bool
+	names       map[string]string
+	versions    map[string]int
+	predeclared map[string]TypeDiscovery
 }
 
 type TypeDiscovery struct {
@@ -27,8 +47,14 @@ type TypeDiscovery struct {
 }
 
 func (l *Locator) FindIdentType(context *LocatorContext, ref *ast.Ident) (TypeDiscovery, error) {
-	locations := context.CandidateLocations(".")
-	return l.findTypeDeclarationInLocations(ref.String(), locations)
+	locations := context.LocalLocations()
+	if !ref.IsExported() && len(locations) > 1 {
+		// Dot-imported packages bring only their exported declarations into
+		// the scope of the file.
+		locations = locations[:1]
+	}
+	discovery, err := l.findTypeDeclarationInLocations(ref.String(), locations)
+	return discovery, l.errorAt(ref.Pos(), nil, err)
 }
 
 func (l *Locator) FindSelectorType(context *LocatorContext, ref *ast.SelectorExpr) (TypeDiscovery, error) {
@@ -36,8 +62,9 @@ func (l *Locator) FindSelectorType(context *LocatorContext, ref *ast.SelectorExp
 	if !ok {
 		panic("Selector expression is not a reference!")
 	}
-	locations := context.CandidateLocations(aliasIdent.String())
-	return l.findTypeDeclarationInLocations(ref.Sel.String(), locations)
+	locations := l.importedLocations(context, aliasIdent.String())
+	discovery, err := l.findTypeDeclarationInLocations(ref.Sel.String(), locations)
+	return discovery, l.errorAt(ref.Pos(), nil, err)
 }
 
 func (l *Locator) findTypeDeclarationInLocations(name string, candidateLocations []string) (TypeDiscovery, error) {
@@ -77,12 +104,19 @@ func (l *Locator) discoverTypes(location string) ([]TypeDiscovery, error) {
 		return nil, err
 	}
 
-	pkgs, err := parser.ParseDir(token.NewFileSet(), sourcePath, nil, parser.AllErrors)
+	// Test files are left out, as nothing they declare can be referred to
+	// from other packages.
+	notTest := func(fi fs.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}
+	pkgs, err := parser.ParseDir(l.fset, sourcePath, notTest, parser.AllErrors|parser.ParseComments)
 	if err != nil {
 		return nil, err
 	}
 
 	discoveries = make([]TypeDiscovery, 0)
+	methods := make([]methodDeclaration, 0)
+	values := make(map[string]bool)
 	for _, pkg := range pkgs {
 		for _, file := range pkg.Files {
 			for spec := range internal.EachTypeSpecificationInFile(file) {
@@ -92,12 +126,122 @@ func (l *Locator) discoverTypes(location string) ([]TypeDiscovery, error) {
 					Spec:     spec,
 				})
 			}
+			for _, decl := range file.Decls {
+				switch d := decl.(type) {
+				case *ast.FuncDecl:
+					if d.Recv != nil {
+						methods = append(methods, methodDeclaration{FuncDecl: d, File: file})
+					} else {
+						values[d.Name.Name] = true
+					}
+				case *ast.GenDecl:
+					for _, spec := range d.Specs {
+						if valueSpec, ok := spec.(*ast.ValueSpec); ok {
+							for _, name := range valueSpec.Names {
+								values[name.Name] = true
+							}
+						}
+					}
+				}
+			}
 		}
 	}
 	l.cache[location] = discoveries
+	l.methodCache[location] = methods
+	l.valueCache[location] = values
+	l.names[location] = packageName(location, pkgs)
 	return discoveries, nil
 }
 
+// packageName returns the name of the package parsed from the location.
+// Should several packages be found, e.g. due to files excluded by build
+// constraints, the one with the name assumed from the import path wins.
+func packageName(location string, pkgs map[string]*ast.Package) string {
+	assumed := assumedPackageName(location)
+	var names []string
+	for name := range pkgs {
+		if name == assumed {
+			return name
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return assumed
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+// assumedPackageName returns the name of the package conventionally assumed
+// from its import path, e.g. yaml for gopkg.in/yaml.v3, bar for
+// github.com/foo/go-bar and chi for github.com/go-chi/chi/v5.
+func assumedPackageName(location string) string {
+	base := path.Base(location)
+	if strings.HasPrefix(base, "v") {
+		if _, err := strconv.Atoi(base[1:]); err == nil && path.Dir(location) != "." {
+			base = path.Base(path.Dir(location))
+		}
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
+
+// importedName returns the name of the package imported from the location,
+// or the name assumed from its import path if the package cannot be parsed,
+// so that the error is reported once its types are looked up.
+func (l *Locator) importedName(location string) string {
+	if _, err := l.discoverTypes(location); err != nil {
+		return assumedPackageName(location)
+	}
+	return l.names[location]
+}
+
+// findValueLocation returns the first of the candidate locations which
+// declares a package-level function, variable or constant with the specified
+// name.
+func (l *Locator) findValueLocation(name string, candidateLocations []string) (string, bool, error) {
+	for _, location := range candidateLocations {
+		if _, err := l.discoverTypes(location); err != nil {
+			return "", false, err
+		}
+		if l.valueCache[location][name] {
+			return location, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// methodDeclaration is the declaration of a method along with the file it
+// is declared in.
+type methodDeclaration struct {
+	*ast.FuncDecl
+	File *ast.File
+}
+
+// findMethodDeclarations returns the declarations of all methods with the
+// specified receiver type name in the specified location.
+func (l *Locator) findMethodDeclarations(typeName string, location string) ([]methodDeclaration, error) {
+	if _, err := l.discoverTypes(location); err != nil {
+		return nil, err
+	}
+	result := []methodDeclaration{}
+	for _, method := range l.methodCache[location] {
+		recvType := method.Recv.List[0].Type
+		if star, ok := recvType.(*ast.StarExpr); ok {
+			recvType = star.X
+		}
+		if id, ok := recvType.(*ast.Ident); ok && id.Name == typeName {
+			result = append(result, method)
+		}
+	}
+	return result, nil
+}
+
 type TypeNotFoundError struct {
 	Name string
 }
@@ -106,6 +250,65 @@ func (e *TypeNotFoundError) Error() string {
 	return fmt.Sprintf("Could not find '%s' type.", e.Name)
 }
 
+// DuplicateMethodError is returned when an interface declares, usually via
+// embedded interfaces, methods with the same name and different signatures.
+type DuplicateMethodError struct {
+	Name       string
+	Signature1 string
+	Signature2 string
+}
+
+func (e *DuplicateMethodError) Error() string {
+	return fmt.Sprintf("Duplicate method '%s' with conflicting signatures '%s' and '%s'.", e.Name, e.Signature1, e.Signature2)
+}
+
+// ConstraintError is returned when an interface cannot be implemented, as it
+// is restricted by type constraints and can be used as a constraint only.
+type ConstraintError struct {
+	Location   string
+	Constraint string
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("interface in '%s' is restricted by type constraint '%s' and cannot be implemented", e.Location, e.Constraint)
+}
+
+type ValueNotFoundError struct {
+	Name string
+}
+
+func (e *ValueNotFoundError) Error() string {
+	return fmt.Sprintf("Could not resolve exported value '%s'.", e.Name)
+}
+
+// NotExportedError is returned when the generated code would refer to a
+// declaration which is not exported by the package of another location.
+type NotExportedError struct {
+	Name     string
+	Location string
+	Target   string
+}
+
+func (e *NotExportedError) Error() string {
+	return fmt.Sprintf("'%s' is not exported by '%s' and cannot be referred to from '%s'.", e.Name, e.Location, e.Target)
+}
+
+// InternalPackageError is returned when the generated code would import an
+// internal package, which cannot be imported from outside of the tree of
+// packages rooted at the parent of its internal directory.
+type InternalPackageError struct {
+	Location string
+	Root     string
+	Target   string
+}
+
+func (e *InternalPackageError) Error() string {
+	if e.Root == "" {
+		return fmt.Sprintf("Package '%s' is internal to the standard library and cannot be imported from '%s'.", e.Location, e.Target)
+	}
+	return fmt.Sprintf("Package '%s' is internal to '%s' and cannot be imported from '%s'; write the implementations to a package within '%s' instead.", e.Location, e.Root, e.Target, e.Root)
+}
+
 func NewSingleLocationContext(location string) *LocatorContext {
 	return &LocatorContext{
 		imports: []importEntry{
@@ -117,6 +320,9 @@ func NewSingleLocationContext(location string) *LocatorContext {
 	}
 }
 
+// NewASTFileLocatorContext returns the context of the declarations of the
+// file, in the package in the specified location, which refer to the
+// packages imported by the file.
 func NewASTFileLocatorContext(astFile *ast.File, location string) *LocatorContext {
 	imports := []importEntry{
 		{
@@ -131,6 +337,10 @@ func NewASTFileLocatorContext(astFile *ast.File, location string) *LocatorContex
 				if importSpec.Name != nil {
 					imp.Alias = importSpec.Name.String()
 				}
+				if imp.Alias == "_" {
+					// Blank imports declare no names.
+					continue
+				}
 				imp.Location = strings.Trim(importSpec.Path.Value, "\"")
 				imports = append(imports, imp)
 			}
@@ -142,24 +352,45 @@ func NewASTFileLocatorContext(astFile *ast.File, location string) *LocatorContex
 }
 
 type LocatorContext struct {
-	imports []importEntry
+	imports    []importEntry
+	typeParams map[string]typeParam
 }
 
+// importEntry is an import of a context. The package of the context itself
+// is imported under the "." alias, ahead of the dot-imported packages, and
+// unaliased imports have an empty alias.
 type importEntry struct {
 	Alias    string
 	Location string
 }
 
-func (c *LocatorContext) CandidateLocations(alias string) []string {
-	if alias == "." {
-		return c.LocalLocations()
-	}
-	if location, found := c.AliasedLocation(alias); found {
+// importedLocations returns the locations of the packages which the context
+// refers to by the specified name, i.e. the package imported under the name
+// as an alias or, failing that, the packages with the name imported without
+// an alias. The names of the packages are read from their sources, as they
+// need not match their import paths, e.g. gopkg.in/yaml.v3 declares package
+// yaml.
+func (l *Locator) importedLocations(context *LocatorContext, name string) []string {
+	if location, found := context.AliasedLocation(name); found {
 		return []string{location}
 	}
-	return c.NonLocalNonAliasedLocations(alias)
+	// The packages whose names are conventionally assumed from their import
+	// paths are looked up first, so that the rest are rarely parsed.
+	unaliased := context.UnaliasedLocations()
+	sort.SliceStable(unaliased, func(i, j int) bool {
+		return assumedPackageName(unaliased[i]) == name && assumedPackageName(unaliased[j]) != name
+	})
+	for _, location := range unaliased {
+		if l.importedName(location) == name {
+			return []string{location}
+		}
+	}
+	return nil
 }
 
+// LocalLocations returns the location of the package of the context,
+// followed by the locations of the dot-imported packages, which declare the
+// unqualified identifiers of the context.
 func (c *LocatorContext) LocalLocations() []string {
 	result := []string{}
 	for _, imp := range c.imports {
@@ -170,10 +401,12 @@ func (c *LocatorContext) LocalLocations() []string {
 	return result
 }
 
-func (c *LocatorContext) NonLocalNonAliasedLocations(alias string) []string {
+// UnaliasedLocations returns the locations of the packages imported without
+// an alias, which are referred to by their package names.
+func (c *LocatorContext) UnaliasedLocations() []string {
 	result := []string{}
 	for _, imp := range c.imports {
-		if imp.Alias == "" && strings.HasSuffix(imp.Location, alias) {
+		if imp.Alias == "" {
 			result = append(result, imp.Location)
 		}
 	}
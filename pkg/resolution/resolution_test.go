@@ -0,0 +1,77 @@
+package resolution
+
+import (
+	"go/ast"
+	"reflect"
+	"testing"
+)
+
+func TestAssumedPackageName(t *testing.T) {
+	tests := []struct {
+		location string
+		want     string
+	}{
+		{"io", "io"},
+		{"net/http", "http"},
+		{"gopkg.in/yaml.v3", "yaml"},
+		{"github.com/foo/go-bar", "bar"},
+		{"github.com/foo/bar-baz", "bar"},
+		{"github.com/foo/go-yaml.v2", "yaml"},
+		{"github.com/go-chi/chi/v5", "chi"},
+		{"github.com/foo/go-bar/v2", "bar"},
+		{"github.com/foo/vendor", "vendor"},
+		{"github.com/foo/v", "v"},
+		{"v2", "v2"},
+	}
+
+	for _, test := range tests {
+		if got := assumedPackageName(test.location); got != test.want {
+			t.Errorf("assumedPackageName(%q) = %q, want %q", test.location, got, test.want)
+		}
+	}
+}
+
+func TestImportedLocations(t *testing.T) {
+	const imports = testdataPath + "/imports"
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"al", []string{imports + "/aliased"}},
+		{"bar", []string{imports + "/go-bar"}},
+		{"yaml", []string{imports + "/yaml.v3"}},
+		{"chi", []string{imports + "/chi/v5"}},
+		{"other", []string{imports + "/misnamed"}},
+		// Packages are referred to by their names, unless aliased.
+		{"aliased", nil},
+		{"misnamed", nil},
+		{"v5", nil},
+		{"dot", nil},
+	}
+
+	l := NewLocator()
+	context := fileContext(t, l, imports, "Imports")
+	for _, test := range tests {
+		if got := l.importedLocations(context, test.name); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("importedLocations(%q) = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDotImportedLocations(t *testing.T) {
+	const imports = testdataPath + "/imports"
+	l := NewLocator()
+	context := fileContext(t, l, imports, "Imports")
+
+	want := []string{imports, imports + "/dot"}
+	if got := context.LocalLocations(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("LocalLocations() = %v, want %v", got, want)
+	}
+	d, err := l.FindIdentType(context, ast.NewIdent("Dotted"))
+	if err != nil {
+		t.Fatalf("FindIdentType() error = %v", err)
+	}
+	if d.Location != imports+"/dot" {
+		t.Fatalf("Dotted found in %s, want the dot-imported package", d.Location)
+	}
+}
@@ -2,24 +2,60 @@ package resolution
 
 import (
 	"go/ast"
-
-	"github.com/Bo0mer/gentools/pkg/internal"
+	"go/types"
+	"strings"
 )
 
 type Importer interface {
 	AddImport(pkgName, location string) string
 }
 
-func NewResolver(importer Importer, locator *Locator) *Resolver {
+// NewResolver returns a resolver which imports the referred packages into
+// the package in targetPath. Unless targetPath is empty, references to
+// declarations which are not visible from that package are reported.
+func NewResolver(importer Importer, locator *Locator, targetPath string) *Resolver {
 	return &Resolver{
-		importer: importer,
-		locator:  locator,
+		importer:   importer,
+		locator:    locator,
+		targetPath: targetPath,
 	}
 }
 
 type Resolver struct {
-	importer Importer
-	locator  *Locator
+	importer   Importer
+	locator    *Locator
+	targetPath string
+}
+
+// CheckVisibility returns an error if the declaration with the specified
+// name, found in the specified location, cannot be referred to from the
+// target package, as it is not exported, or as it is declared by an internal
+// package which the target package cannot import.
+func (r *Resolver) CheckVisibility(name, location string) error {
+	if r.targetPath == "" || location == "" || location == r.targetPath {
+		return nil
+	}
+	if !ast.IsExported(name) {
+		return &NotExportedError{Name: name, Location: location, Target: r.targetPath}
+	}
+	if root, ok := internalRoot(location); ok && r.targetPath != root && !strings.HasPrefix(r.targetPath, root+"/") {
+		return &InternalPackageError{Location: location, Root: root, Target: r.targetPath}
+	}
+	return nil
+}
+
+// internalRoot returns the import path of the tree to which the package in
+// the location is internal, e.g. a/b for a/b/internal/c, or false if the
+// package is not internal. The packages of the standard library which are
+// internal to it have an empty root.
+func internalRoot(location string) (string, bool) {
+	elements := strings.Split(location, "/")
+	for i := len(elements) - 1; i >= 0; i-- {
+		if elements[i] == "internal" {
+			return strings.Join(elements[:i], "/"), true
+		}
+	}
+	return "", false
 }
 
 func (r *Resolver) ResolveType(context *LocatorContext, astType ast.Expr) (ast.Expr, error) {
@@ -44,18 +80,73 @@ func (r *Resolver) ResolveType(context *LocatorContext, astType ast.Expr) (ast.E
 		return r.resolveInterfaceType(context, t)
 	case *ast.Ellipsis:
 		return r.resolveEllipsisType(context, t)
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		return r.resolveInstantiation(context, t)
+	case *ast.BinaryExpr:
+		return r.resolveUnion(context, t)
+	case *ast.UnaryExpr:
+		return r.resolveUnderlyingTerm(context, t)
 	}
 	return astType, nil
 }
 
+// ResolveValue resolves a reference to a package-level value, such as a
+// function, against the generated stub's namespace.
+func (r *Resolver) ResolveValue(context *LocatorContext, ref ast.Expr) (ast.Expr, error) {
+	var name string
+	var locations []string
+	switch t := ref.(type) {
+	case *ast.Ident:
+		name = t.String()
+		locations = context.LocalLocations()
+	case *ast.SelectorExpr:
+		aliasIdent, ok := t.X.(*ast.Ident)
+		if !ok {
+			return nil, &ValueNotFoundError{Name: types.ExprString(ref)}
+		}
+		name = t.Sel.String()
+		locations = r.locator.importedLocations(context, aliasIdent.String())
+	default:
+		return nil, &ValueNotFoundError{Name: types.ExprString(ref)}
+	}
+	if !ast.IsExported(name) {
+		return nil, &ValueNotFoundError{Name: types.ExprString(ref)}
+	}
+	location, found, err := r.locator.findValueLocation(name, locations)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &ValueNotFoundError{Name: types.ExprString(ref)}
+	}
+	if err := r.CheckVisibility(name, location); err != nil {
+		return nil, err
+	}
+	al := r.importer.AddImport("", location)
+	return &ast.SelectorExpr{
+		X:   ast.NewIdent(al),
+		Sel: ast.NewIdent(name),
+	}, nil
+}
+
 func (r *Resolver) resolveIdent(context *LocatorContext, ident *ast.Ident) (ast.Expr, error) {
-	if r.isBuiltIn(ident.String()) {
+	if _, ok := context.TypeParam(ident.Name); ok {
+		return ident, nil
+	}
+	predeclared, err := r.locator.isPredeclared(context, ident.Name)
+	if err != nil {
+		return nil, err
+	}
+	if predeclared {
 		return ident, nil
 	}
 	discovery, err := r.locator.FindIdentType(context, ident)
 	if err != nil {
 		return nil, err
 	}
+	if err := r.CheckVisibility(ident.Name, discovery.Location); err != nil {
+		return nil, r.locator.errorAt(ident.Pos(), nil, err)
+	}
 	al := r.importer.AddImport("", discovery.Location)
 	return &ast.SelectorExpr{
 		X:   ast.NewIdent(al),
@@ -68,6 +159,9 @@ func (r *Resolver) resolveSelectorExpr(context *LocatorContext, expr *ast.Select
 	if err != nil {
 		return nil, err
 	}
+	if err := r.CheckVisibility(expr.Sel.Name, discovery.Location); err != nil {
+		return nil, r.locator.errorAt(expr.Pos(), nil, err)
+	}
 	al := r.importer.AddImport("", discovery.Location)
 	return &ast.SelectorExpr{
 		X:   ast.NewIdent(al),
@@ -76,104 +170,159 @@ func (r *Resolver) resolveSelectorExpr(context *LocatorContext, expr *ast.Select
 }
 
 func (r *Resolver) resolveArrayType(context *LocatorContext, astType *ast.ArrayType) (ast.Expr, error) {
-	var err error
-	astType.Elt, err = r.ResolveType(context, astType.Elt)
-	return astType, err
+	elt, err := r.ResolveType(context, astType.Elt)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *astType
+	resolved.Elt = elt
+	return &resolved, nil
 }
 
 func (r *Resolver) resolveMapType(context *LocatorContext, astType *ast.MapType) (ast.Expr, error) {
-	var err error
-	astType.Key, err = r.ResolveType(context, astType.Key)
+	key, err := r.ResolveType(context, astType.Key)
 	if err != nil {
 		return nil, err
 	}
-	astType.Value, err = r.ResolveType(context, astType.Value)
+	value, err := r.ResolveType(context, astType.Value)
 	if err != nil {
 		return nil, err
 	}
-	return astType, nil
+	resolved := *astType
+	resolved.Key, resolved.Value = key, value
+	return &resolved, nil
 }
 
 func (r *Resolver) resolveChanType(context *LocatorContext, astType *ast.ChanType) (ast.Expr, error) {
-	var err error
-	astType.Value, err = r.ResolveType(context, astType.Value)
-	return astType, err
+	value, err := r.ResolveType(context, astType.Value)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *astType
+	resolved.Value = value
+	return &resolved, nil
 }
 
 func (r *Resolver) resolveStarType(context *LocatorContext, astType *ast.StarExpr) (ast.Expr, error) {
-	var err error
-	astType.X, err = r.ResolveType(context, astType.X)
-	return astType, err
+	x, err := r.ResolveType(context, astType.X)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *astType
+	resolved.X = x
+	return &resolved, nil
 }
 
 func (r *Resolver) resolveFuncType(context *LocatorContext, astType *ast.FuncType) (ast.Expr, error) {
-	var err error
-	for param := range internal.EachFieldInFieldList(astType.Params) {
-		param.Type, err = r.ResolveType(context, param.Type)
-		if err != nil {
-			return nil, err
-		}
+	params, err := r.resolveFieldList(context, astType.Params)
+	if err != nil {
+		return nil, err
 	}
-	for result := range internal.EachFieldInFieldList(astType.Results) {
-		result.Type, err = r.ResolveType(context, result.Type)
-		if err != nil {
-			return nil, err
-		}
+	results, err := r.resolveFieldList(context, astType.Results)
+	if err != nil {
+		return nil, err
 	}
-	return astType, nil
+	resolved := *astType
+	resolved.Params, resolved.Results = params, results
+	return &resolved, nil
 }
 
 func (r *Resolver) resolveStructType(context *LocatorContext, astType *ast.StructType) (ast.Expr, error) {
-	var err error
-	for field := range internal.EachFieldInFieldList(astType.Fields) {
-		field.Type, err = r.ResolveType(context, field.Type)
-		if err != nil {
-			return nil, err
-		}
+	fields, err := r.resolveFieldList(context, astType.Fields)
+	if err != nil {
+		return nil, err
 	}
-	return astType, nil
+	resolved := *astType
+	resolved.Fields = fields
+	return &resolved, nil
 }
 
 func (r *Resolver) resolveInterfaceType(context *LocatorContext, astType *ast.InterfaceType) (ast.Expr, error) {
-	var err error
-	for field := range internal.EachFieldInFieldList(astType.Methods) {
-		field.Type, err = r.ResolveType(context, field.Type)
+	methods, err := r.resolveFieldList(context, astType.Methods)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *astType
+	resolved.Methods = methods
+	return &resolved, nil
+}
+
+func (r *Resolver) resolveEllipsisType(context *LocatorContext, astType *ast.Ellipsis) (ast.Expr, error) {
+	elt, err := r.ResolveType(context, astType.Elt)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *astType
+	resolved.Elt = elt
+	return &resolved, nil
+}
+
+// resolveInstantiation resolves both the generic type and the type arguments
+// of an instantiated generic type, e.g. Box[User].
+func (r *Resolver) resolveInstantiation(context *LocatorContext, astType ast.Expr) (ast.Expr, error) {
+	generic, args, _ := instantiation(astType)
+	x, err := r.ResolveType(context, generic)
+	if err != nil {
+		return nil, err
+	}
+	indices := make([]ast.Expr, len(args))
+	for i, arg := range args {
+		indices[i], err = r.ResolveType(context, arg)
 		if err != nil {
 			return nil, err
 		}
 	}
-	return astType, nil
+	if len(indices) == 1 {
+		return &ast.IndexExpr{X: x, Index: indices[0]}, nil
+	}
+	return &ast.IndexListExpr{X: x, Indices: indices}, nil
 }
 
-func (r *Resolver) resolveEllipsisType(context *LocatorContext, astType *ast.Ellipsis) (ast.Expr, error) {
-	var err error
-	astType.Elt, err = r.ResolveType(context, astType.Elt)
-	return astType, err
-}
-
-// isBuiltIn should return whether a type, specified by its name,
-// is native to the language or not.
-func (r *Resolver) isBuiltIn(name string) bool {
-	switch name {
-	case "bool":
-		return true
-	case "byte":
-		return true
-	case "complex64", "complex128":
-		return true
-	case "error":
-		return true
-	case "float32", "float64":
-		return true
-	case "int", "int8", "int16", "int32", "int64":
-		return true
-	case "rune", "string":
-		return true
-	case "uint", "uint8", "uint16", "uint32", "uint64":
-		return true
-	case "uintptr":
-		return true
-	default:
-		return false
+// resolveUnion resolves the terms of a union in a type constraint, e.g.
+// int | Celsius.
+func (r *Resolver) resolveUnion(context *LocatorContext, astType *ast.BinaryExpr) (ast.Expr, error) {
+	x, err := r.ResolveType(context, astType.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := r.ResolveType(context, astType.Y)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *astType
+	resolved.X, resolved.Y = x, y
+	return &resolved, nil
+}
+
+// resolveUnderlyingTerm resolves a term of a type constraint which matches
+// the types with the specified underlying type, e.g. ~string.
+func (r *Resolver) resolveUnderlyingTerm(context *LocatorContext, astType *ast.UnaryExpr) (ast.Expr, error) {
+	x, err := r.ResolveType(context, astType.X)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *astType
+	resolved.X = x
+	return &resolved, nil
+}
+
+// resolveFieldList returns a copy of the field list with the types of its
+// fields resolved. The source AST is left untouched, as it is cached by the
+// locator and may be resolved again for another model.
+func (r *Resolver) resolveFieldList(context *LocatorContext, fieldList *ast.FieldList) (*ast.FieldList, error) {
+	if fieldList == nil {
+		return nil, nil
+	}
+	resolved := *fieldList
+	resolved.List = make([]*ast.Field, len(fieldList.List))
+	for i, field := range fieldList.List {
+		fieldType, err := r.ResolveType(context, field.Type)
+		if err != nil {
+			return nil, err
+		}
+		resolvedField := *field
+		resolvedField.Type = fieldType
+		resolved.List[i] = &resolvedField
 	}
+	return &resolved, nil
 }
@@ -0,0 +1,171 @@
+package resolution
+
+import (
+	"bufio"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Bo0mer/gentools/pkg/internal"
+)
+
+// universe maps the names of the predeclared types to the minor version of
+// Go 1.x which introduced them.
+var universe = map[string]int{
+	"bool":       0,
+	"byte":       0,
+	"complex64":  0,
+	"complex128": 0,
+	"error":      0,
+	"float32":    0,
+	"float64":    0,
+	"int":        0,
+	"int8":       0,
+	"int16":      0,
+	"int32":      0,
+	"int64":      0,
+	"rune":       0,
+	"string":     0,
+	"uint":       0,
+	"uint8":      0,
+	"uint16":     0,
+	"uint32":     0,
+	"uint64":     0,
+	"uintptr":    0,
+	"any":        18,
+	"comparable": 18,
+}
+
+// latestGoVersion is assumed for packages outside of any module, which are
+// not restricted to a version of the language.
+const latestGoVersion = int(^uint(0) >> 1)
+
+// typeParam is a type parameter in scope, along with the context in which
+// its constraint is seen.
+type typeParam struct {
+	Constraint ast.Expr
+	context    *LocatorContext
+}
+
+// DeclareTypeParams brings the type parameters into the scope of the
+// context. Their constraints are seen in the same context, as they may refer
+// to each other.
+func (c *LocatorContext) DeclareTypeParams(params *ast.FieldList) {
+	scope := map[string]typeParam{}
+	for name, param := range c.typeParams {
+		scope[name] = param
+	}
+	if params != nil {
+		for _, field := range params.List {
+			for _, name := range field.Names {
+				scope[name.Name] = typeParam{Constraint: field.Type, context: c}
+			}
+		}
+	}
+	c.typeParams = scope
+}
+
+// TypeParam returns the constraint of the type parameter with the specified
+// name, if it is in the scope of the context.
+func (c *LocatorContext) TypeParam(name string) (ast.Expr, bool) {
+	param, ok := c.typeParams[name]
+	return param.Constraint, ok
+}
+
+// isPredeclared returns whether the identifier, as seen in the specified
+// context, refers to a predeclared type. Type parameters and package-level
+// declarations shadow the predeclared types, and only the types known to the
+// version of Go required by the module of the package are predeclared.
+func (l *Locator) isPredeclared(context *LocatorContext, name string) (bool, error) {
+	if _, ok := context.typeParams[name]; ok {
+		return false, nil
+	}
+	since, ok := universe[name]
+	if !ok {
+		return false, nil
+	}
+	locations := context.LocalLocations()
+	if len(locations) == 0 || locations[0] == "" {
+		// The context of the predeclared types themselves.
+		return true, nil
+	}
+	version, err := l.goVersion(locations[0])
+	if err != nil {
+		return false, err
+	}
+	if version < since {
+		return false, nil
+	}
+	// The dot-imported packages declare no predeclared names, as these are
+	// not exported.
+	_, found, err := l.findTypeDeclarationInLocation(name, locations[0])
+	if err != nil || found {
+		return false, err
+	}
+	return true, nil
+}
+
+// goVersion returns the minor version of Go 1.x, declared in the go.mod file
+// of the module containing the package at the specified location.
+func (l *Locator) goVersion(location string) (int, error) {
+	if version, ok := l.versions[location]; ok {
+		return version, nil
+	}
+	dir, err := internal.ImportToDir(location)
+	if err != nil {
+		return 0, err
+	}
+	version := latestGoVersion
+	for {
+		if v, found, err := readGoVersion(filepath.Join(dir, "go.mod")); err != nil {
+			return 0, err
+		} else if found {
+			version = v
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	l.versions[location] = version
+	return version, nil
+}
+
+// readGoVersion returns the minor version of the go directive of the go.mod
+// file, or false if there is no such file.
+func readGoVersion(path string) (int, bool, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "go" {
+			continue
+		}
+		parts := strings.Split(fields[1], ".")
+		if len(parts) < 2 || parts[0] != "1" {
+			break
+		}
+		minor, err := strconv.Atoi(parts[1])
+		if err != nil {
+			break
+		}
+		return minor, true, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, false, err
+	}
+	// Modules without a go directive are assumed to require Go 1.16.
+	return 16, true, nil
+}
@@ -0,0 +1,91 @@
+// Package embeds declares the interfaces whose method sets are flattened in
+// tests.
+package embeds
+
+import (
+	"context"
+	"io"
+
+	store "github.com/Bo0mer/gentools/pkg/resolution/testdata/embeds/other"
+)
+
+// Getter declares the same Get method as store.Store.
+type Getter interface {
+	// Get returns the item with the key.
+	Get(ctx context.Context, key string) (*store.Item, error)
+}
+
+// Closer declares the same Close method as io.Closer.
+type Closer interface {
+	Close() error
+}
+
+// Writer declares a Close method too.
+type Writer interface {
+	Write(p []byte) (n int, err error)
+	Close() error
+}
+
+// Plain declares its methods itself.
+type Plain interface {
+	Open() error
+	Close() error
+}
+
+// Overlapping embeds interfaces which declare the same Close method.
+type Overlapping interface {
+	Writer
+	Closer
+	io.Closer
+	Flush() error
+}
+
+// Redeclared declares a method of an embedded interface again.
+type Redeclared interface {
+	Closer
+	Close() error
+}
+
+// Nested embeds an interface which embeds overlapping interfaces.
+type Nested interface {
+	Overlapping
+	io.Writer
+}
+
+// Remote embeds an interface from a package imported with an alias, which
+// declares the same Get method as Getter.
+type Remote interface {
+	Getter
+	store.Store
+}
+
+// Shutdowner declares a Close method conflicting with the one of Closer.
+type Shutdowner interface {
+	Close(ctx context.Context) error
+}
+
+// Conflicting embeds interfaces which declare conflicting Close methods.
+type Conflicting interface {
+	Closer
+	Shutdowner
+}
+
+// ConflictingRedeclared declares a method conflicting with the one of an
+// embedded interface.
+type ConflictingRedeclared interface {
+	Closer
+	Close(force bool) error
+}
+
+// ConflictingRemote embeds interfaces from a package imported with an
+// alias, which declare conflicting Get methods.
+type ConflictingRemote interface {
+	store.Store
+	store.Versioned
+}
+
+// ConflictingNested embeds an interface which embeds interfaces declaring
+// conflicting methods.
+type ConflictingNested interface {
+	Conflicting
+}
@@ -0,0 +1,21 @@
+// Package other declares interfaces embedded from another package.
+package other
+
+import (
+	stdcontext "context"
+)
+
+// Item is a stored item.
+type Item struct{}
+
+// Store declares the same Get method as embeds.Getter, referring to the
+// context package by another name.
+type Store interface {
+	Get(c stdcontext.Context, key string) (*Item, error)
+	Put(c stdcontext.Context, item *Item) error
+}
+
+// Versioned declares a Get method conflicting with the one of Store.
+type Versioned interface {
+	Get(c stdcontext.Context, key string, version int) (*Item, error)
+}
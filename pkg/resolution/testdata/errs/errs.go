@@ -0,0 +1,65 @@
+// Package errs declares the types of the results whose detection as errors
+// is tested.
+package errs
+
+import (
+	failures "github.com/Bo0mer/gentools/pkg/resolution/testdata/errs/other"
+)
+
+// Coded declares the Error method itself.
+type Coded interface {
+	Error() string
+	Code() int
+}
+
+// MyError embeds the error interface.
+type MyError interface {
+	error
+	Temporary() bool
+}
+
+// Wrapped embeds an interface embedding the error interface.
+type Wrapped interface {
+	MyError
+	Unwrap() error
+}
+
+// Remote embeds an error interface of another package.
+type Remote interface {
+	failures.Failure
+}
+
+// Stringer has no Error method.
+type Stringer interface {
+	String() string
+}
+
+// BadError has an Error method with another signature.
+type BadError interface {
+	Error() int
+}
+
+// Impl implements error with a pointer receiver.
+type Impl struct{}
+
+func (*Impl) Error() string { return "impl" }
+
+// ValueImpl implements error with a value receiver.
+type ValueImpl struct{}
+
+func (ValueImpl) Error() string { return "value impl" }
+
+// Named is a defined type of the error interface.
+type Named error
+
+// Alias is an alias of the error interface.
+type Alias = error
+
+// ImplAlias is an alias of an error implementation.
+type ImplAlias = Impl
+
+// Code is not an error.
+type Code int
+
+// Plain has no methods.
+type Plain struct{}
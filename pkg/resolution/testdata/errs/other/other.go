@@ -0,0 +1,13 @@
+// Package other declares errors referred to from another package.
+package other
+
+// Failure is an error interface.
+type Failure interface {
+	error
+	Retry() bool
+}
+
+// Err implements error with a pointer receiver.
+type Err struct{}
+
+func (*Err) Error() string { return "err" }
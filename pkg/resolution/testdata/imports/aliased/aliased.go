@@ -0,0 +1,5 @@
+// Package aliased is imported with an alias.
+package aliased
+
+// Aliased is a type.
+type Aliased struct{}
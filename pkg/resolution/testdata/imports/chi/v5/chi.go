@@ -0,0 +1,5 @@
+// Package chi is imported from a path with a major version element.
+package chi
+
+// Router is a type.
+type Router struct{}
@@ -0,0 +1,5 @@
+// Package dot is dot-imported.
+package dot
+
+// Dotted is a type.
+type Dotted struct{}
@@ -0,0 +1,5 @@
+// Package bar is imported from a go- prefixed path.
+package bar
+
+// Bar is a type.
+type Bar struct{}
@@ -0,0 +1,22 @@
+// Package imports imports packages whose names are assumed from their
+// import paths.
+package imports
+
+import (
+	al "github.com/Bo0mer/gentools/pkg/resolution/testdata/imports/aliased"
+	"github.com/Bo0mer/gentools/pkg/resolution/testdata/imports/chi/v5"
+	. "github.com/Bo0mer/gentools/pkg/resolution/testdata/imports/dot"
+	"github.com/Bo0mer/gentools/pkg/resolution/testdata/imports/go-bar"
+	"github.com/Bo0mer/gentools/pkg/resolution/testdata/imports/misnamed"
+	"github.com/Bo0mer/gentools/pkg/resolution/testdata/imports/yaml.v3"
+)
+
+// Imports refers to all imported packages.
+type Imports struct {
+	Aliased al.Aliased
+	Bar     bar.Bar
+	Node    yaml.Node
+	Router  chi.Router
+	Other   other.Other
+	Dotted  Dotted
+}
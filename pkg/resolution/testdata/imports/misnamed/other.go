@@ -0,0 +1,5 @@
+// Package other is imported from a path which does not match its name.
+package other
+
+// Other is a type.
+type Other struct{}
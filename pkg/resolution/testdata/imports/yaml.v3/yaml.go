@@ -0,0 +1,5 @@
+// Package yaml is imported from a path with a version suffix.
+package yaml
+
+// Node is a type.
+type Node struct{}
@@ -0,0 +1,125 @@
+package resolution
+
+import (
+	"go/ast"
+)
+
+// IsValidator returns whether values of the specified type, as seen in the
+// specified context, can be validated with a Validate() error method. Both
+// value and pointer receivers are accepted, as arguments are addressable.
+func (l *Locator) IsValidator(context *LocatorContext, astType ast.Expr) (bool, error) {
+	pointer := false
+	if star, ok := astType.(*ast.StarExpr); ok {
+		pointer = true
+		astType = star.X
+	}
+
+	var discovery TypeDiscovery
+	var err error
+	switch t := astType.(type) {
+	case *ast.Ident:
+		if _, ok := context.TypeParam(t.Name); ok {
+			return false, nil
+		}
+		var predeclared bool
+		predeclared, err = l.isPredeclared(context, t.Name)
+		if err != nil || predeclared {
+			return false, err
+		}
+		discovery, err = l.FindIdentType(context, t)
+	case *ast.SelectorExpr:
+		if _, ok := t.X.(*ast.Ident); !ok {
+			return false, nil
+		}
+		discovery, err = l.FindSelectorType(context, t)
+	case *ast.InterfaceType:
+		if pointer {
+			return false, nil
+		}
+		return l.interfaceHasValidate(context, t)
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return l.declarationHasValidate(discovery, pointer)
+}
+
+func (l *Locator) declarationHasValidate(d TypeDiscovery, pointer bool) (bool, error) {
+	context := NewASTFileLocatorContext(d.File, d.Location)
+	switch t := d.Spec.Type.(type) {
+	case *ast.InterfaceType:
+		// Pointers to interfaces have no methods.
+		if pointer {
+			return false, nil
+		}
+		return l.interfaceHasValidate(context, t)
+	case *ast.Ident, *ast.SelectorExpr:
+		// Aliases share the method set of the aliased type.
+		if d.Spec.Assign != 0 {
+			if pointer {
+				return l.IsValidator(context, &ast.StarExpr{X: t})
+			}
+			return l.IsValidator(context, t)
+		}
+	}
+
+	methods, err := l.findMethodDeclarations(d.Spec.Name.Name, d.Location)
+	if err != nil {
+		return false, err
+	}
+	for _, method := range methods {
+		if method.Name.Name == "Validate" && isValidateMethodType(method.Type) {
+			return true, nil
+		}
+	}
+
+	// Methods of embedded fields are promoted.
+	if strct, ok := d.Spec.Type.(*ast.StructType); ok {
+		for _, field := range strct.Fields.List {
+			if len(field.Names) > 0 {
+				continue
+			}
+			ok, err := l.IsValidator(context, field.Type)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+	}
+	return false, nil
+}
+
+func (l *Locator) interfaceHasValidate(context *LocatorContext, iface *ast.InterfaceType) (bool, error) {
+	for _, field := range iface.Methods.List {
+		switch t := field.Type.(type) {
+		case *ast.FuncType:
+			if field.Names[0].Name == "Validate" && isValidateMethodType(t) {
+				return true, nil
+			}
+		case *ast.Ident, *ast.SelectorExpr:
+			ok, err := l.IsValidator(context, t)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+	}
+	return false, nil
+}
+
+// isValidateMethodType returns whether funcType matches the signature
+// Validate() error.
+func isValidateMethodType(funcType *ast.FuncType) bool {
+	if funcType.Params != nil && len(funcType.Params.List) > 0 {
+		return false
+	}
+	if funcType.Results == nil || len(funcType.Results.List) != 1 {
+		return false
+	}
+	result := funcType.Results.List[0]
+	if len(result.Names) > 1 {
+		return false
+	}
+	id, ok := result.Type.(*ast.Ident)
+	return ok && id.Name == "error"
+}
@@ -23,6 +23,17 @@ func ToSnakeCase(in string) string {
 	return string(out)
 }
 
+// ToUnexported lower cases the first letter of the provided identifier. E.g.:
+//   DoWork -> doWork
+func ToUnexported(in string) string {
+	if in == "" {
+		return in
+	}
+	runes := []rune(in)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
 // FieldsAsAnonymous removes the names out of fields and returns only the types. E.g.:
 //   (result string, err error) -> (string, error)
 //
@@ -36,3 +47,23 @@ func FieldsAsAnonymous(fields []*ast.Field) []*ast.Field {
 	}
 	return result
 }
+
+// FieldsWithoutEllipsis replaces variadic field types with slices of the same element type. E.g.:
+//   (arg1 int, arg2 ...string) -> (arg1 int, arg2 []string)
+//
+// It creates and returns a copy of the list and does not modify the provided one.
+func FieldsWithoutEllipsis(fields []*ast.Field) []*ast.Field {
+	result := make([]*ast.Field, len(fields))
+	for i, field := range fields {
+		result[i] = &ast.Field{
+			Names: field.Names,
+			Type:  field.Type,
+		}
+		if ellipsisType, ok := field.Type.(*ast.Ellipsis); ok {
+			result[i].Type = &ast.ArrayType{
+				Elt: ellipsisType.Elt,
+			}
+		}
+	}
+	return result
+}